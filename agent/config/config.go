@@ -1,6 +1,7 @@
 package agent_config
 
 import (
+	commonStructs "github.com/LanceLRQ/deer-executor/v2/common/structs"
 	"github.com/gookit/config/v2"
 	"path/filepath"
 )
@@ -11,9 +12,20 @@ type GRPCConfigDefinition struct {
 }
 
 type JudgementConfigDefinition struct {
-	ProblemRoot       string `mapstructure:"problem_root"`        // 题目根目录
-	SystemLibraryRoot string `mapstructure:"system_library_root"` // testlib.h等的库目录
-	SessionRoot       string `mapstructure:"session_root"`        // 评测会话目录
+	ProblemRoot       string                         `mapstructure:"problem_root"`            // 题目根目录
+	SystemLibraryRoot string                         `mapstructure:"system_library_root"`     // testlib.h等的库目录
+	SessionRoot       string                         `mapstructure:"session_root"`            // 评测会话目录
+	CgroupRoot        string                         `mapstructure:"cgroup_root"`             // cgroup v2根目录（可选，为空时不启用cgroup）
+	Seccomp           bool                           `mapstructure:"seccomp"`                 // 启用系统调用过滤
+	CPUSupervisor     int                            `mapstructure:"cpu_supervisor_interval"` // CPU时间监控的轮询间隔(ms)，0表示使用默认值，负数表示不启用
+	IdlenessLimit     int                            `mapstructure:"idleness_limit"`          // 空闲时间限制(ms)，0表示题目没有设置真实时间限制时使用默认值，负数表示不启用
+	MemoryStrategy    string                         `mapstructure:"memory_strategy"`         // 内存峰值的统计方式：minflt, maxrss, vmhwm, cgroup_peak，为空时自动选择
+	TimelineInterval  int                            `mapstructure:"timeline_interval"`       // 资源使用时间线的采样间隔(ms)，0表示不启用
+	Isolation         commonStructs.IsolationOptions `mapstructure:"isolation"`               // 命名空间隔离
+	UserPool          commonStructs.UserPoolOptions  `mapstructure:"user_pool"`               // 非特权用户池
+	CPUCores          []int                          `mapstructure:"cpu_cores"`               // 评测专用的CPU核心，每个会话独占一个，为空时不绑定
+	ParallelCases     int                            `mapstructure:"parallel_cases"`          // 每个会话同时运行的测试数据数量，0或1表示依次运行
+	MaxParallelCases  int                            `mapstructure:"max_parallel_cases"`      // 所有会话同时运行的测试数据总数，0表示不限制
 }
 
 var GRPCConfig GRPCConfigDefinition
@@ -26,13 +26,13 @@ func convertToJSON(a interface{}) string {
 	return string(rel)
 }
 
-func (s *JudgementServiceServerImpl) StartJudgement(context context.Context, request *rpc.JudgementRequest) (*rpc.JudgementResponse, error) {
+func (s *JudgementServiceServerImpl) StartJudgement(ctx context.Context, request *rpc.JudgementRequest) (*rpc.JudgementResponse, error) {
 	// check requset
 	if err := checkJudgeRequsetArgs(request); err != nil {
 		return nil, err
 	}
 
-	judgeResult, presistFile, err := runRpcJudge(request)
+	judgeResult, presistFile, err := runRpcJudge(ctx, request)
 	if err != nil {
 		return nil, err
 	}
@@ -42,5 +42,7 @@ func (s *JudgementServiceServerImpl) StartJudgement(context context.Context, req
 		SessionId:         judgeResult.SessionID,
 		ResultData:        convertToJSON(judgeResult),
 		ResultPackageFile: presistFile,
+		Score:             judgeResult.Score,
+		MaxScore:          judgeResult.MaxScore,
 	}, nil
 }
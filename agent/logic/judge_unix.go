@@ -4,49 +4,116 @@
 package logic
 
 import (
+	"context"
 	"fmt"
 	agentConfig "github.com/LanceLRQ/deer-executor/v2/agent/config"
 	"github.com/LanceLRQ/deer-executor/v2/agent/rpc"
 	"github.com/LanceLRQ/deer-executor/v2/common/persistence"
 	"github.com/LanceLRQ/deer-executor/v2/common/persistence/result"
+	"github.com/LanceLRQ/deer-executor/v2/common/sandbox"
 	commonStructs "github.com/LanceLRQ/deer-executor/v2/common/structs"
 	"github.com/LanceLRQ/deer-executor/v2/common/utils"
 	"github.com/LanceLRQ/deer-executor/v2/executor"
 	"github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
+	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 )
 
 // JudgementRunOption options for StartJudgement
 type JudgementRunOption struct {
-	Persistence *persistence.JudgeResultPersisOptions
-	Clean       bool
-	ConfigFile  string
-	WorkDir     string
-	ShowLog     bool
-	LogLevel    int
-	Language    string
-	LibraryDir  string
-	CodeStr     string
-	SessionID   string
-	SessionDir  string
-	SessionRoot string
+	Persistence    *persistence.JudgeResultPersisOptions
+	Clean          bool
+	ConfigFile     string
+	WorkDir        string
+	ShowLog        bool
+	LogLevel       int
+	Language       string
+	LibraryDir     string
+	CodeStr        string
+	Outputs        []byte
+	SessionID      string
+	SessionDir     string
+	SessionRoot    string
+	CgroupRoot     string
+	Seccomp        bool
+	CPUSupervisor  int
+	IdlenessLimit  int
+	MemoryStrategy string
+	Timeline       int
+	Isolation      commonStructs.IsolationOptions
+	UID            int
+	GID            int
+	CPUCore        int
+	Parallelism    int
+	CasePool       *executor.CasePool
+}
+
+var (
+	userPool     *sandbox.UserPool
+	userPoolErr  error
+	userPoolOnce sync.Once
+)
+
+// 获取非特权用户池，没有启用时返回nil
+func getUserPool() (*sandbox.UserPool, error) {
+	userPoolOnce.Do(func() {
+		opts := agentConfig.JudgementConfig.UserPool
+		if opts.Size > 0 {
+			userPool, userPoolErr = sandbox.NewUserPool(opts.UIDStart, opts.GIDStart, opts.Size)
+		}
+	})
+	return userPool, userPoolErr
+}
+
+var (
+	corePool     *sandbox.CorePool
+	corePoolErr  error
+	corePoolOnce sync.Once
+)
+
+// 获取独占CPU核心池，没有配置核心时返回nil
+func getCorePool() (*sandbox.CorePool, error) {
+	corePoolOnce.Do(func() {
+		cores := agentConfig.JudgementConfig.CPUCores
+		if len(cores) > 0 {
+			corePool, corePoolErr = sandbox.NewCorePool(cores)
+		}
+	})
+	return corePool, corePoolErr
+}
+
+var (
+	casePool     *executor.CasePool
+	casePoolOnce sync.Once
+)
+
+// 获取所有会话共享的测试数据并发限制，没有设置时返回nil
+func getCasePool() *executor.CasePool {
+	casePoolOnce.Do(func() {
+		if size := agentConfig.JudgementConfig.MaxParallelCases; size > 0 {
+			casePool = executor.NewCasePool(size)
+		}
+	})
+	return casePool
 }
 
 func checkJudgeRequsetArgs(request *rpc.JudgementRequest) error {
 	if strings.TrimSpace(request.ProblemDir) == "" {
 		return errors.Errorf("invalid problem path")
 	}
-	if strings.TrimSpace(request.Code) == "" {
+	// 提交答案题提交的是输出文件的压缩包，没有代码
+	if strings.TrimSpace(request.Code) == "" && len(request.Outputs) == 0 {
 		return errors.Errorf("invalid code file path")
 	}
 	return nil
 }
 
-func runOnceJudge(options *JudgementRunOption) (*commonStructs.JudgeResult, *executor.JudgeSession, error) {
+func runOnceJudge(ctx context.Context, options *JudgementRunOption) (*commonStructs.JudgeResult, *executor.JudgeSession, error) {
 	// create session
 	session, err := executor.NewSessionWithLog(options.ConfigFile, options.ShowLog, options.LogLevel)
 	if err != nil {
@@ -83,13 +150,37 @@ func runOnceJudge(options *JudgementRunOption) (*commonStructs.JudgeResult, *exe
 	session.SessionID = options.SessionID
 	session.SessionRoot = options.SessionRoot
 	session.SessionDir = options.SessionDir
+	// 提交答案题：把提交的压缩包写入会话目录，作为评测的输出文件
+	if session.JudgeConfig.OutputOnly && len(options.Outputs) > 0 {
+		outputsFile := path.Join(session.SessionDir, "outputs.zip")
+		if err := ioutil.WriteFile(outputsFile, options.Outputs, 0644); err != nil {
+			return nil, nil, errors.Errorf("write output archive error: %s", err.Error())
+		}
+		session.CodeFile = outputsFile
+	}
+	session.CgroupRoot = options.CgroupRoot
+	session.Seccomp = options.Seccomp
+	if options.CPUSupervisor != 0 {
+		session.CPUSupervisorInterval = options.CPUSupervisor
+	}
+	if options.IdlenessLimit != 0 {
+		session.IdlenessLimit = options.IdlenessLimit
+	}
+	session.MemoryStrategy = options.MemoryStrategy
+	session.TimelineInterval = options.Timeline
+	session.Isolation = options.Isolation
+	session.UID = options.UID
+	session.GID = options.GID
+	session.CPUCore = options.CPUCore
+	session.Parallelism = options.Parallelism
+	session.CasePool = options.CasePool
 	// start judgement
-	judgeResult := session.RunJudge()
+	judgeResult := session.RunJudgeContext(ctx)
 	return &judgeResult, session, nil
 }
 
-func startRealJudgement(options *JudgementRunOption) (*executor.JudgeSession, *commonStructs.JudgeResult, error) {
-	judgeResult, judgeSession, err := runOnceJudge(options)
+func startRealJudgement(ctx context.Context, options *JudgementRunOption) (*executor.JudgeSession, *commonStructs.JudgeResult, error) {
+	judgeResult, judgeSession, err := runOnceJudge(ctx, options)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -110,7 +201,8 @@ func startRealJudgement(options *JudgementRunOption) (*executor.JudgeSession, *c
 }
 
 // intro
-func runRpcJudge(request *rpc.JudgementRequest) (*commonStructs.JudgeResult, string, error) {
+// ctx被取消（如客户端断开连接）时取消评测
+func runRpcJudge(ctx context.Context, request *rpc.JudgementRequest) (*commonStructs.JudgeResult, string, error) {
 	// build and check workdir
 	workDir := path.Join(agentConfig.JudgementConfig.ProblemRoot, request.ProblemDir)
 	if wd, err := os.Stat(workDir); os.IsNotExist(err) || (wd != nil && !wd.IsDir()) {
@@ -152,17 +244,57 @@ func runRpcJudge(request *rpc.JudgementRequest) (*commonStructs.JudgeResult, str
 
 	// build options
 	rOptions := &JudgementRunOption{
-		Clean:       request.CleanSession,
-		ShowLog:     showLog,
-		LogLevel:    logLevel,
-		WorkDir:     workDir,
-		ConfigFile:  configFile,
-		Language:    request.Language,
-		LibraryDir:  agentConfig.JudgementConfig.SystemLibraryRoot,
-		CodeStr:     request.Code,
-		SessionID:   sessionID,
-		SessionDir:  sessionDir,
-		SessionRoot: agentConfig.JudgementConfig.SessionRoot,
+		Clean:          request.CleanSession,
+		ShowLog:        showLog,
+		LogLevel:       logLevel,
+		WorkDir:        workDir,
+		ConfigFile:     configFile,
+		Language:       request.Language,
+		LibraryDir:     agentConfig.JudgementConfig.SystemLibraryRoot,
+		CodeStr:        request.Code,
+		Outputs:        request.Outputs,
+		SessionID:      sessionID,
+		SessionDir:     sessionDir,
+		SessionRoot:    agentConfig.JudgementConfig.SessionRoot,
+		CgroupRoot:     agentConfig.JudgementConfig.CgroupRoot,
+		Seccomp:        agentConfig.JudgementConfig.Seccomp,
+		CPUSupervisor:  agentConfig.JudgementConfig.CPUSupervisor,
+		IdlenessLimit:  agentConfig.JudgementConfig.IdlenessLimit,
+		MemoryStrategy: agentConfig.JudgementConfig.MemoryStrategy,
+		Timeline:       agentConfig.JudgementConfig.TimelineInterval,
+		Isolation:      agentConfig.JudgementConfig.Isolation,
+		CPUCore:        -1,
+		Parallelism:    agentConfig.JudgementConfig.ParallelCases,
+		CasePool:       getCasePool(),
+	}
+
+	// 租用一个非特权用户，评测结束后杀死它残留的进程并归还
+	pool, err := getUserPool()
+	if err != nil {
+		return nil, "", err
+	}
+	if pool != nil {
+		user, err := pool.Acquire(ctx)
+		if err != nil {
+			return nil, "", err
+		}
+		defer pool.Release(user)
+		rOptions.UID = user.UID
+		rOptions.GID = user.GID
+	}
+
+	// 租用一个独占的CPU核心，评测结束后归还
+	cores, err := getCorePool()
+	if err != nil {
+		return nil, "", err
+	}
+	if cores != nil {
+		core, err := cores.Acquire(ctx)
+		if err != nil {
+			return nil, "", err
+		}
+		defer cores.Release(core)
+		rOptions.CPUCore = core
 	}
 
 	persistFile := ""          // set empty
@@ -174,7 +306,7 @@ func runRpcJudge(request *rpc.JudgementRequest) (*commonStructs.JudgeResult, str
 	}
 
 	// start judge
-	_, judgeResult, err := startRealJudgement(rOptions)
+	_, judgeResult, err := startRealJudgement(ctx, rOptions)
 	if err != nil {
 		return nil, "", err
 	}
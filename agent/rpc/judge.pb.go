@@ -150,6 +150,12 @@ const (
 	JudgeFlag_SpecialJudgeError JudgeFlag = 11
 	// Special Judge Checker Finish, Need Standard Checkup
 	JudgeFlag_SpecialJudgeRequireChecker JudgeFlag = 12
+	// Restricted Function
+	JudgeFlag_RF JudgeFlag = 13
+	// Idleness Limit Exceeded
+	JudgeFlag_ILE JudgeFlag = 14
+	// Judgement Cancelled
+	JudgeFlag_Cancelled JudgeFlag = 15
 )
 
 // Enum value maps for JudgeFlag.
@@ -168,6 +174,9 @@ var (
 		10: "SpecialJudgeTimeout",
 		11: "SpecialJudgeError",
 		12: "SpecialJudgeRequireChecker",
+		13: "RF",
+		14: "ILE",
+		15: "Cancelled",
 	}
 	JudgeFlag_value = map[string]int32{
 		"AC":                         0,
@@ -183,6 +192,9 @@ var (
 		"SpecialJudgeTimeout":        10,
 		"SpecialJudgeError":          11,
 		"SpecialJudgeRequireChecker": 12,
+		"RF":                         13,
+		"ILE":                        14,
+		"Cancelled":                  15,
 	}
 )
 
@@ -230,6 +242,7 @@ type JudgementRequest struct {
 	SignResult        bool          `protobuf:"varint,13,opt,name=sign_result,json=signResult,proto3" json:"sign_result,omitempty"`                             // 对评测记录进行GPG签名
 	GpgKey            string        `protobuf:"bytes,14,opt,name=gpg_key,json=gpgKey,proto3" json:"gpg_key,omitempty"`                                          // Base64编码后的GPG私钥
 	GpgPassphrase     string        `protobuf:"bytes,15,opt,name=gpg_passphrase,json=gpgPassphrase,proto3" json:"gpg_passphrase,omitempty"`                     // GPG私钥的密码
+	Outputs           []byte        `protobuf:"bytes,16,opt,name=outputs,proto3" json:"outputs,omitempty"`                                                      // 提交答案题的输出文件(zip压缩包)
 }
 
 func (x *JudgementRequest) Reset() {
@@ -348,6 +361,13 @@ func (x *JudgementRequest) GetGpgPassphrase() string {
 	return ""
 }
 
+func (x *JudgementRequest) GetOutputs() []byte {
+	if x != nil {
+		return x.Outputs
+	}
+	return nil
+}
+
 type JudgementResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -356,7 +376,9 @@ type JudgementResponse struct {
 	JudgeFlag         JudgeFlag `protobuf:"varint,1,opt,name=JudgeFlag,proto3,enum=rpc.JudgeFlag" json:"JudgeFlag,omitempty"` // 评测结果状态
 	ResultData        string    `protobuf:"bytes,2,opt,name=ResultData,proto3" json:"ResultData,omitempty"`                   // 评测结果数据(JSON格式序列化成文本，结构为commonStructs.JudgeResult)
 	ResultPackageFile string    `protobuf:"bytes,3,opt,name=ResultPackageFile,proto3" json:"ResultPackageFile,omitempty"`     // 评测运行数据打包文件(外部根据ID访问/%agent_config.JudgementConfig.SessionRoot%/%ResultPackageFile%)
-	SessionId         string    `protobuf:"bytes,4,opt,name=SessionId,proto3" json:"SessionId,omitempty"`                     // 评测Session的ID(外部根据ID访问/%server_config.JudgementConfig.SessionRoot%/%SessionID%)
+	SessionId         string    `protobuf:"bytes,4,opt,name=SessionId,proto3" json:"SessionId,omitempty"`                     // 评测Session的ID(外部根据ID访问/%agent_config.JudgementConfig.SessionRoot%/%SessionID%)
+	Score             float64   `protobuf:"fixed64,5,opt,name=Score,proto3" json:"Score,omitempty"`                           // 得分(子任务得分之和或测试数据的加权得分)
+	MaxScore          float64   `protobuf:"fixed64,6,opt,name=MaxScore,proto3" json:"MaxScore,omitempty"`                     // 满分
 }
 
 func (x *JudgementResponse) Reset() {
@@ -419,6 +441,20 @@ func (x *JudgementResponse) GetSessionId() string {
 	return ""
 }
 
+func (x *JudgementResponse) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *JudgementResponse) GetMaxScore() float64 {
+	if x != nil {
+		return x.MaxScore
+	}
+	return 0
+}
+
 type PingRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -516,7 +552,7 @@ var File_protos_judge_proto protoreflect.FileDescriptor
 
 var file_protos_judge_proto_rawDesc = []byte{
 	0x0a, 0x12, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2f, 0x6a, 0x75, 0x64, 0x67, 0x65, 0x2e, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x12, 0x03, 0x72, 0x70, 0x63, 0x22, 0xcf, 0x03, 0x0a, 0x10, 0x4a, 0x75,
+	0x72, 0x6f, 0x74, 0x6f, 0x12, 0x03, 0x72, 0x70, 0x63, 0x22, 0xe9, 0x03, 0x0a, 0x10, 0x4a, 0x75,
 	0x64, 0x67, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12,
 	0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x63, 0x6f,
 	0x64, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x70, 0x72, 0x6f, 0x62, 0x6c, 0x65, 0x6d, 0x5f, 0x64, 0x69,
@@ -545,50 +581,57 @@ var file_protos_judge_proto_rawDesc = []byte{
 	0x18, 0x0e, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x67, 0x70, 0x67, 0x4b, 0x65, 0x79, 0x12, 0x25,
 	0x0a, 0x0e, 0x67, 0x70, 0x67, 0x5f, 0x70, 0x61, 0x73, 0x73, 0x70, 0x68, 0x72, 0x61, 0x73, 0x65,
 	0x18, 0x0f, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x67, 0x70, 0x67, 0x50, 0x61, 0x73, 0x73, 0x70,
-	0x68, 0x72, 0x61, 0x73, 0x65, 0x4a, 0x04, 0x08, 0x07, 0x10, 0x0a, 0x22, 0xad, 0x01, 0x0a, 0x11,
-	0x4a, 0x75, 0x64, 0x67, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x12, 0x2c, 0x0a, 0x09, 0x4a, 0x75, 0x64, 0x67, 0x65, 0x46, 0x6c, 0x61, 0x67, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x0e, 0x32, 0x0e, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x4a, 0x75, 0x64, 0x67, 0x65,
-	0x46, 0x6c, 0x61, 0x67, 0x52, 0x09, 0x4a, 0x75, 0x64, 0x67, 0x65, 0x46, 0x6c, 0x61, 0x67, 0x12,
-	0x1e, 0x0a, 0x0a, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x44, 0x61, 0x74, 0x61, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x0a, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x44, 0x61, 0x74, 0x61, 0x12,
-	0x2c, 0x0a, 0x11, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x50, 0x61, 0x63, 0x6b, 0x61, 0x67, 0x65,
-	0x46, 0x69, 0x6c, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x11, 0x52, 0x65, 0x73, 0x75,
-	0x6c, 0x74, 0x50, 0x61, 0x63, 0x6b, 0x61, 0x67, 0x65, 0x46, 0x69, 0x6c, 0x65, 0x12, 0x1c, 0x0a,
-	0x09, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x09, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x64, 0x22, 0x0d, 0x0a, 0x0b, 0x50,
-	0x69, 0x6e, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x38, 0x0a, 0x0c, 0x50, 0x69,
-	0x6e, 0x67, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x72, 0x65,
-	0x61, 0x64, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x72, 0x65, 0x61, 0x64, 0x79,
-	0x12, 0x12, 0x0a, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04,
-	0x74, 0x69, 0x6d, 0x65, 0x2a, 0x44, 0x0a, 0x0d, 0x4a, 0x75, 0x64, 0x67, 0x65, 0x4c, 0x6f, 0x67,
-	0x4c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x09, 0x0a, 0x05, 0x55, 0x4e, 0x53, 0x45, 0x54, 0x10, 0x00,
-	0x12, 0x09, 0x0a, 0x05, 0x44, 0x45, 0x42, 0x55, 0x47, 0x10, 0x01, 0x12, 0x08, 0x0a, 0x04, 0x49,
-	0x4e, 0x46, 0x4f, 0x10, 0x02, 0x12, 0x08, 0x0a, 0x04, 0x57, 0x41, 0x52, 0x4e, 0x10, 0x03, 0x12,
-	0x09, 0x0a, 0x05, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x04, 0x2a, 0x29, 0x0a, 0x0c, 0x43, 0x6f,
-	0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x54, 0x79, 0x70, 0x65, 0x12, 0x0f, 0x0a, 0x0b, 0x4e, 0x4f,
-	0x5f, 0x43, 0x4f, 0x4d, 0x50, 0x52, 0x45, 0x53, 0x53, 0x10, 0x00, 0x12, 0x08, 0x0a, 0x04, 0x47,
-	0x5a, 0x49, 0x50, 0x10, 0x01, 0x2a, 0xb3, 0x01, 0x0a, 0x09, 0x4a, 0x75, 0x64, 0x67, 0x65, 0x46,
-	0x6c, 0x61, 0x67, 0x12, 0x06, 0x0a, 0x02, 0x41, 0x43, 0x10, 0x00, 0x12, 0x06, 0x0a, 0x02, 0x50,
-	0x45, 0x10, 0x01, 0x12, 0x07, 0x0a, 0x03, 0x54, 0x4c, 0x45, 0x10, 0x02, 0x12, 0x07, 0x0a, 0x03,
-	0x4d, 0x4c, 0x45, 0x10, 0x03, 0x12, 0x06, 0x0a, 0x02, 0x57, 0x41, 0x10, 0x04, 0x12, 0x06, 0x0a,
-	0x02, 0x52, 0x45, 0x10, 0x05, 0x12, 0x07, 0x0a, 0x03, 0x4f, 0x4c, 0x45, 0x10, 0x06, 0x12, 0x06,
-	0x0a, 0x02, 0x43, 0x45, 0x10, 0x07, 0x12, 0x06, 0x0a, 0x02, 0x53, 0x45, 0x10, 0x08, 0x12, 0x0b,
-	0x0a, 0x07, 0x52, 0x65, 0x4a, 0x75, 0x64, 0x67, 0x65, 0x10, 0x09, 0x12, 0x17, 0x0a, 0x13, 0x53,
-	0x70, 0x65, 0x63, 0x69, 0x61, 0x6c, 0x4a, 0x75, 0x64, 0x67, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x6f,
-	0x75, 0x74, 0x10, 0x0a, 0x12, 0x15, 0x0a, 0x11, 0x53, 0x70, 0x65, 0x63, 0x69, 0x61, 0x6c, 0x4a,
-	0x75, 0x64, 0x67, 0x65, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x10, 0x0b, 0x12, 0x1e, 0x0a, 0x1a, 0x53,
-	0x70, 0x65, 0x63, 0x69, 0x61, 0x6c, 0x4a, 0x75, 0x64, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x69,
-	0x72, 0x65, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x65, 0x72, 0x10, 0x0c, 0x32, 0x80, 0x01, 0x0a, 0x10,
-	0x4a, 0x75, 0x64, 0x67, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65,
-	0x12, 0x2b, 0x0a, 0x04, 0x50, 0x69, 0x6e, 0x67, 0x12, 0x10, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x50,
-	0x69, 0x6e, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x11, 0x2e, 0x72, 0x70, 0x63,
-	0x2e, 0x50, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3f, 0x0a,
-	0x0e, 0x53, 0x74, 0x61, 0x72, 0x74, 0x4a, 0x75, 0x64, 0x67, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x12,
-	0x15, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x4a, 0x75, 0x64, 0x67, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x4a, 0x75, 0x64,
-	0x67, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x06,
-	0x5a, 0x04, 0x2f, 0x72, 0x70, 0x63, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x68, 0x72, 0x61, 0x73, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x73,
+	0x18, 0x10, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x73, 0x4a,
+	0x04, 0x08, 0x07, 0x10, 0x0a, 0x22, 0xdf, 0x01, 0x0a, 0x11, 0x4a, 0x75, 0x64, 0x67, 0x65, 0x6d,
+	0x65, 0x6e, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2c, 0x0a, 0x09, 0x4a,
+	0x75, 0x64, 0x67, 0x65, 0x46, 0x6c, 0x61, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0e,
+	0x2e, 0x72, 0x70, 0x63, 0x2e, 0x4a, 0x75, 0x64, 0x67, 0x65, 0x46, 0x6c, 0x61, 0x67, 0x52, 0x09,
+	0x4a, 0x75, 0x64, 0x67, 0x65, 0x46, 0x6c, 0x61, 0x67, 0x12, 0x1e, 0x0a, 0x0a, 0x52, 0x65, 0x73,
+	0x75, 0x6c, 0x74, 0x44, 0x61, 0x74, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x52,
+	0x65, 0x73, 0x75, 0x6c, 0x74, 0x44, 0x61, 0x74, 0x61, 0x12, 0x2c, 0x0a, 0x11, 0x52, 0x65, 0x73,
+	0x75, 0x6c, 0x74, 0x50, 0x61, 0x63, 0x6b, 0x61, 0x67, 0x65, 0x46, 0x69, 0x6c, 0x65, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x11, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x50, 0x61, 0x63, 0x6b,
+	0x61, 0x67, 0x65, 0x46, 0x69, 0x6c, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x53, 0x65, 0x73, 0x73, 0x69,
+	0x6f, 0x6e, 0x49, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x53, 0x65, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x4d,
+	0x61, 0x78, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x01, 0x52, 0x08, 0x4d,
+	0x61, 0x78, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x22, 0x0d, 0x0a, 0x0b, 0x50, 0x69, 0x6e, 0x67, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x38, 0x0a, 0x0c, 0x50, 0x69, 0x6e, 0x67, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x72, 0x65, 0x61, 0x64, 0x79, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x72, 0x65, 0x61, 0x64, 0x79, 0x12, 0x12, 0x0a, 0x04,
+	0x74, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x74, 0x69, 0x6d, 0x65,
+	0x2a, 0x44, 0x0a, 0x0d, 0x4a, 0x75, 0x64, 0x67, 0x65, 0x4c, 0x6f, 0x67, 0x4c, 0x65, 0x76, 0x65,
+	0x6c, 0x12, 0x09, 0x0a, 0x05, 0x55, 0x4e, 0x53, 0x45, 0x54, 0x10, 0x00, 0x12, 0x09, 0x0a, 0x05,
+	0x44, 0x45, 0x42, 0x55, 0x47, 0x10, 0x01, 0x12, 0x08, 0x0a, 0x04, 0x49, 0x4e, 0x46, 0x4f, 0x10,
+	0x02, 0x12, 0x08, 0x0a, 0x04, 0x57, 0x41, 0x52, 0x4e, 0x10, 0x03, 0x12, 0x09, 0x0a, 0x05, 0x45,
+	0x52, 0x52, 0x4f, 0x52, 0x10, 0x04, 0x2a, 0x29, 0x0a, 0x0c, 0x43, 0x6f, 0x6d, 0x70, 0x72, 0x65,
+	0x73, 0x73, 0x54, 0x79, 0x70, 0x65, 0x12, 0x0f, 0x0a, 0x0b, 0x4e, 0x4f, 0x5f, 0x43, 0x4f, 0x4d,
+	0x50, 0x52, 0x45, 0x53, 0x53, 0x10, 0x00, 0x12, 0x08, 0x0a, 0x04, 0x47, 0x5a, 0x49, 0x50, 0x10,
+	0x01, 0x2a, 0xd3, 0x01, 0x0a, 0x09, 0x4a, 0x75, 0x64, 0x67, 0x65, 0x46, 0x6c, 0x61, 0x67, 0x12,
+	0x06, 0x0a, 0x02, 0x41, 0x43, 0x10, 0x00, 0x12, 0x06, 0x0a, 0x02, 0x50, 0x45, 0x10, 0x01, 0x12,
+	0x07, 0x0a, 0x03, 0x54, 0x4c, 0x45, 0x10, 0x02, 0x12, 0x07, 0x0a, 0x03, 0x4d, 0x4c, 0x45, 0x10,
+	0x03, 0x12, 0x06, 0x0a, 0x02, 0x57, 0x41, 0x10, 0x04, 0x12, 0x06, 0x0a, 0x02, 0x52, 0x45, 0x10,
+	0x05, 0x12, 0x07, 0x0a, 0x03, 0x4f, 0x4c, 0x45, 0x10, 0x06, 0x12, 0x06, 0x0a, 0x02, 0x43, 0x45,
+	0x10, 0x07, 0x12, 0x06, 0x0a, 0x02, 0x53, 0x45, 0x10, 0x08, 0x12, 0x0b, 0x0a, 0x07, 0x52, 0x65,
+	0x4a, 0x75, 0x64, 0x67, 0x65, 0x10, 0x09, 0x12, 0x17, 0x0a, 0x13, 0x53, 0x70, 0x65, 0x63, 0x69,
+	0x61, 0x6c, 0x4a, 0x75, 0x64, 0x67, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x10, 0x0a,
+	0x12, 0x15, 0x0a, 0x11, 0x53, 0x70, 0x65, 0x63, 0x69, 0x61, 0x6c, 0x4a, 0x75, 0x64, 0x67, 0x65,
+	0x45, 0x72, 0x72, 0x6f, 0x72, 0x10, 0x0b, 0x12, 0x1e, 0x0a, 0x1a, 0x53, 0x70, 0x65, 0x63, 0x69,
+	0x61, 0x6c, 0x4a, 0x75, 0x64, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x69, 0x72, 0x65, 0x43, 0x68,
+	0x65, 0x63, 0x6b, 0x65, 0x72, 0x10, 0x0c, 0x12, 0x06, 0x0a, 0x02, 0x52, 0x46, 0x10, 0x0d, 0x12,
+	0x07, 0x0a, 0x03, 0x49, 0x4c, 0x45, 0x10, 0x0e, 0x12, 0x0d, 0x0a, 0x09, 0x43, 0x61, 0x6e, 0x63,
+	0x65, 0x6c, 0x6c, 0x65, 0x64, 0x10, 0x0f, 0x32, 0x80, 0x01, 0x0a, 0x10, 0x4a, 0x75, 0x64, 0x67,
+	0x65, 0x6d, 0x65, 0x6e, 0x74, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x2b, 0x0a, 0x04,
+	0x50, 0x69, 0x6e, 0x67, 0x12, 0x10, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x50, 0x69, 0x6e, 0x67, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x11, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x50, 0x69, 0x6e,
+	0x67, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3f, 0x0a, 0x0e, 0x53, 0x74, 0x61,
+	0x72, 0x74, 0x4a, 0x75, 0x64, 0x67, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x15, 0x2e, 0x72, 0x70,
+	0x63, 0x2e, 0x4a, 0x75, 0x64, 0x67, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x16, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x4a, 0x75, 0x64, 0x67, 0x65, 0x6d, 0x65,
+	0x6e, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x06, 0x5a, 0x04, 0x2f, 0x72,
+	0x70, 0x63, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -1,11 +1,20 @@
 package agent
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
+	"encoding/json"
+	agentConfig "github.com/LanceLRQ/deer-executor/v2/agent/config"
+	"github.com/LanceLRQ/deer-executor/v2/agent/logic"
 	"github.com/LanceLRQ/deer-executor/v2/agent/rpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"io/ioutil"
 	"log"
+	"net"
+	"os"
+	"path"
 	"testing"
 	"time"
 )
@@ -51,3 +60,114 @@ func TestRpcJudge(t *testing.T) {
 	log.Println(resp.ResultData)
 	log.Println(resp.ResultPackageFile)
 }
+
+// 在本进程里启动评测服务，题目和会话都放在临时目录里
+func startTestServer(t *testing.T, problemRoot string) (rpc.JudgementServiceClient, func()) {
+	sessionRoot, err := ioutil.TempDir("", "deer-agent-sessions-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	agentConfig.JudgementConfig.ProblemRoot = problemRoot
+	agentConfig.JudgementConfig.SessionRoot = sessionRoot
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	srv := grpc.NewServer()
+	rpc.RegisterJudgementServiceServer(srv, &logic.JudgementServiceServerImpl{})
+	go srv.Serve(listener)
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("did not connect: %v", err)
+	}
+	return rpc.NewJudgementServiceClient(conn), func() {
+		_ = conn.Close()
+		srv.Stop()
+		_ = os.RemoveAll(sessionRoot)
+	}
+}
+
+// 把files打包成zip压缩包
+func zipFiles(t *testing.T, files map[string]string) []byte {
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestRpcJudgeOutputOnly(t *testing.T) {
+	// 把APlusB改成提交答案题
+	problemRoot, err := ioutil.TempDir("", "deer-agent-problems-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(problemRoot)
+	problemDir := path.Join(problemRoot, "APlusB")
+	if err = os.Mkdir(problemDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"0.in", "0.out", "1.in", "1.out"} {
+		data, err := ioutil.ReadFile(path.Join("../data/problems/APlusB", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(path.Join(problemDir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	conf := map[string]interface{}{}
+	data, err := ioutil.ReadFile("../data/problems/APlusB/problem.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = json.Unmarshal(data, &conf); err != nil {
+		t.Fatal(err)
+	}
+	conf["output_only"] = true
+	if data, err = json.Marshal(conf); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(path.Join(problemDir, "problem.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	client, stop := startTestServer(t, problemRoot)
+	defer stop()
+	answer := "2\n4\n6\n-1\n-2\n0\n"
+	cases := []struct {
+		name    string
+		outputs []byte
+		flag    rpc.JudgeFlag
+	}{
+		{"accepted", zipFiles(t, map[string]string{"1.out": "4\n", "2.out": answer}), rpc.JudgeFlag_AC},
+		{"wrong answer", zipFiles(t, map[string]string{"1.out": "5\n", "2.out": answer}), rpc.JudgeFlag_WA},
+		{"not an archive", []byte("4\n"), rpc.JudgeFlag_CE},
+	}
+	for _, c := range cases {
+		timeoutContext, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		resp, err := client.StartJudgement(timeoutContext, &rpc.JudgementRequest{
+			ProblemDir:   "APlusB",
+			Outputs:      c.outputs,
+			CleanSession: true,
+		})
+		cancel()
+		if err != nil {
+			t.Fatalf("%s: cannot judge: %v", c.name, err)
+		}
+		if resp.JudgeFlag != c.flag {
+			t.Fatalf("%s: expect %s, got %s: %s", c.name, c.flag, resp.JudgeFlag, resp.ResultData)
+		}
+	}
+	t.Log("OK")
+}
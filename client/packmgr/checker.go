@@ -3,6 +3,7 @@ package packmgr
 import (
 	"context"
 	"fmt"
+	"github.com/LanceLRQ/deer-executor/v2/common/checker"
 	"github.com/LanceLRQ/deer-executor/v2/common/constants"
 	"github.com/LanceLRQ/deer-executor/v2/common/structs"
 	"github.com/LanceLRQ/deer-executor/v2/common/utils"
@@ -39,6 +40,19 @@ func runCheckerCase(session *executor.JudgeSession, caseIndex int) error {
 
 	config := session.JudgeConfig
 
+	// 内置的判题程序直接在当前进程里运行
+	if config.SpecialJudge.Builtin != "" {
+		check, err := checker.Get(config.SpecialJudge.Builtin)
+		if err != nil {
+			return err
+		}
+		ret := check(strings.NewReader(iCase.Input), strings.NewReader(iCase.Output), strings.NewReader(iCase.Answer))
+		iCase.CheckerVerdict = ret.Verdict
+		iCase.CheckerComment = ret.Message
+		iCase.Verdict = iCase.CheckerVerdict == iCase.ExpectedVerdict
+		return nil
+	}
+
 	cPath, err := utils.GetCompiledBinaryFileAbsPath("checker", config.SpecialJudge.Name, session.ConfigDir)
 	if err != nil {
 		return err
@@ -78,6 +92,10 @@ func runCheckerCase(session *executor.JudgeSession, caseIndex int) error {
 
 // 检查是否存在checker
 func isCheckerExists(config *structs.JudgeConfiguration) error {
+	if config.SpecialJudge.Builtin != "" {
+		_, err := checker.Get(config.SpecialJudge.Builtin)
+		return err
+	}
 	cPath, err := utils.GetCompiledBinaryFileAbsPath("checker", config.SpecialJudge.Name, config.ConfigDir)
 	if err != nil {
 		return err
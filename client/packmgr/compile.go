@@ -1,6 +1,7 @@
 package packmgr
 
 import (
+	"context"
 	"fmt"
 	"github.com/LanceLRQ/deer-executor/v2/common/constants"
 	"github.com/LanceLRQ/deer-executor/v2/common/provider"
@@ -27,7 +28,7 @@ func compileTestlibCodeFile(source, name, binRoot, configDir, libraryDir, typeNa
 		return errors.Errorf("cannot find %s's source code", typeName)
 	}
 	compiler := provider.NewGnucppCompileProvider()
-	ok, ceinfo := compiler.ManualCompile(genCodeFile, compileTarget, []string{libraryDir})
+	ok, ceinfo := compiler.ManualCompile(context.Background(), genCodeFile, compileTarget, []string{libraryDir})
 	if ok {
 		fmt.Println("Done.")
 	} else {
@@ -59,7 +60,7 @@ func compileWorkCodeFiles(config structs.JudgeConfiguration, libraryDir string)
 		}
 	}
 	// Checker
-	if config.SpecialJudge.Mode > 0 {
+	if config.SpecialJudge.Mode > 0 && config.SpecialJudge.Builtin == "" {
 		if config.SpecialJudge.Name == "" {
 			return errors.Errorf("please setup special judge checker name")
 		}
@@ -85,6 +86,7 @@ func compileWorkCodeFiles(config structs.JudgeConfiguration, libraryDir string)
 		} else {
 			fmt.Printf("build %s [%s]...", "special judge "+checkerType, config.SpecialJudge.Name)
 			_, err = executor.CompileSpecialJudgeCodeFile(
+				context.Background(),
 				config.SpecialJudge.Checker,
 				config.SpecialJudge.Name,
 				binRoot,
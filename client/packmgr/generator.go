@@ -113,7 +113,7 @@ func initWork(session *executor.JudgeSession, answerCaseIndex uint) error {
 		return err
 	}
 	// 编译程序
-	success, ceinfo := compiler.Compile()
+	success, ceinfo := compiler.Compile(context.Background())
 	if !success {
 		return errors.Errorf("[generator] compile error:\n%s", ceinfo)
 	}
@@ -87,6 +87,46 @@ var RunFlags = []cli.Flag{
 		Value: "./lib",
 		Usage: "library root for special judge, contains \"testlib.h\" and \"bits/stdc++.h\" etc.",
 	},
+	&cli.StringFlag{
+		Name:  "cgroup",
+		Value: "",
+		Usage: "enable cgroup v2 resource control with the given cgroup root (e.g. /sys/fs/cgroup/deer-executor)",
+	},
+	&cli.BoolFlag{
+		Name:  "seccomp",
+		Value: false,
+		Usage: "enable seccomp syscall filter for the target program (Linux only)",
+	},
+	&cli.IntFlag{
+		Name:  "cpu-supervisor",
+		Value: 0,
+		Usage: "polling interval (ms) of the CPU time supervisor, 0 means default (10ms), negative means disabled",
+	},
+	&cli.IntFlag{
+		Name:  "idleness-limit",
+		Value: 0,
+		Usage: "kill the program if its CPU time stays flat for this long (ms), 0 means default (3000ms, only if the problem has no real time limit), negative means disabled",
+	},
+	&cli.StringFlag{
+		Name:  "memory-strategy",
+		Value: "",
+		Usage: "peak memory accounting: minflt, maxrss, vmhwm (sampling /proc, Linux only) or cgroup_peak, empty means cgroup_peak if cgroup is enabled, otherwise minflt",
+	},
+	&cli.IntFlag{
+		Name:  "cpu",
+		Value: -1,
+		Usage: "pin the program and checker to this CPU core for stable timing (Linux only), -1 means not pinned",
+	},
+	&cli.IntFlag{
+		Name:  "parallel",
+		Value: 0,
+		Usage: "number of test cases running at the same time, 0 or 1 means one by one, ignored with --cpu",
+	},
+	&cli.IntFlag{
+		Name:  "timeline",
+		Value: 0,
+		Usage: "sample the CPU time and resident memory of the program at this interval (ms) and attach the timeline to test-case results (Linux only), 0 means disabled",
+	},
 	&cli.StringFlag{
 		Name:  "log-level",
 		Value: "",
@@ -4,6 +4,7 @@
 package run
 
 import (
+	"context"
 	"fmt"
 	"github.com/LanceLRQ/deer-executor/v2/client"
 	"github.com/LanceLRQ/deer-executor/v2/common/constants"
@@ -37,16 +38,23 @@ func runJudgeBenchmark(c *cli.Context, configFile string) error {
 	workDir := c.String("work-dir")
 	// 构建运行选项
 	rOptions := &JudgementRunOption{
-		Clean:       true,
-		ShowLog:     false,
-		LogLevel:    0,
-		WorkDir:     workDir,
-		ConfigFile:  configFile,
-		Language:    c.String("language"),
-		LibraryDir:  c.String("library"),
-		CodePath:    c.Args().Get(1),
-		SessionID:   "",
-		SessionRoot: "",
+		Clean:          true,
+		ShowLog:        false,
+		LogLevel:       0,
+		WorkDir:        workDir,
+		ConfigFile:     configFile,
+		Language:       c.String("language"),
+		LibraryDir:     c.String("library"),
+		CodePath:       c.Args().Get(1),
+		SessionID:      "",
+		SessionRoot:    "",
+		CgroupRoot:     c.String("cgroup"),
+		Seccomp:        c.Bool("seccomp"),
+		CPUSupervisor:  c.Int("cpu-supervisor"),
+		IdlenessLimit:  c.Int("idleness-limit"),
+		MemoryStrategy: c.String("memory-strategy"),
+		CPUCore:        c.Int("cpu"),
+		Parallelism:    c.Int("parallel"),
 	}
 
 	startTime := time.Now().UnixNano()
@@ -55,7 +63,7 @@ func runJudgeBenchmark(c *cli.Context, configFile string) error {
 		if i%10 == 0 {
 			log.Printf("[%d / %d]\n", i, times)
 		}
-		judgeResult, _, err := runOnceJudge(rOptions)
+		judgeResult, _, err := runOnceJudge(context.Background(), rOptions)
 		if err != nil {
 			rel.Message = fmt.Sprintf("break! %s\n", err.Error())
 			log.Print(rel.Message)
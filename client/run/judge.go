@@ -4,6 +4,7 @@
 package run
 
 import (
+	"context"
 	"github.com/LanceLRQ/deer-executor/v2/common/logger"
 	"github.com/LanceLRQ/deer-executor/v2/common/persistence"
 	commonStructs "github.com/LanceLRQ/deer-executor/v2/common/structs"
@@ -14,11 +15,13 @@ import (
 	"github.com/urfave/cli/v2"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 )
 
 // 执行一次完整的评测
-func runOnceJudge(options *JudgementRunOption) (*commonStructs.JudgeResult, *executor.JudgeSession, error) {
+func runOnceJudge(ctx context.Context, options *JudgementRunOption) (*commonStructs.JudgeResult, *executor.JudgeSession, error) {
 	// create session
 	session, err := executor.NewSessionWithLog(options.ConfigFile, options.ShowLog, options.LogLevel)
 	if err != nil {
@@ -54,6 +57,18 @@ func runOnceJudge(options *JudgementRunOption) (*commonStructs.JudgeResult, *exe
 	session.CodeFile = options.CodePath
 	session.SessionID = options.SessionID
 	session.SessionRoot = options.SessionRoot
+	session.CgroupRoot = options.CgroupRoot
+	session.Seccomp = options.Seccomp
+	if options.CPUSupervisor != 0 {
+		session.CPUSupervisorInterval = options.CPUSupervisor
+	}
+	if options.IdlenessLimit != 0 {
+		session.IdlenessLimit = options.IdlenessLimit
+	}
+	session.MemoryStrategy = options.MemoryStrategy
+	session.TimelineInterval = options.Timeline
+	session.CPUCore = options.CPUCore
+	session.Parallelism = options.Parallelism
 	// create session info
 	if session.SessionID == "" {
 		session.SessionID = uuid.NewV1().String()
@@ -69,7 +84,7 @@ func runOnceJudge(options *JudgementRunOption) (*commonStructs.JudgeResult, *exe
 	}
 	session.SessionDir = sessionDir
 	// start judgement
-	judgeResult := session.RunJudge()
+	judgeResult := session.RunJudgeContext(ctx)
 	return &judgeResult, session, nil
 }
 
@@ -119,24 +134,34 @@ func runUserJudge(c *cli.Context, configFile, workDir string) (*commonStructs.Ju
 
 	// 构建运行选项
 	rOptions := &JudgementRunOption{
-		Clean:       !c.Bool("no-clean"),
-		ShowLog:     showLog,
-		LogLevel:    logLevel,
-		WorkDir:     workDir,
-		ConfigFile:  configFile,
-		Language:    c.String("language"),
-		LibraryDir:  c.String("library"),
-		CodePath:    c.Args().Get(1),
-		SessionID:   c.String("session-id"),
-		SessionRoot: c.String("session-root"),
+		Clean:          !c.Bool("no-clean"),
+		ShowLog:        showLog,
+		LogLevel:       logLevel,
+		WorkDir:        workDir,
+		ConfigFile:     configFile,
+		Language:       c.String("language"),
+		LibraryDir:     c.String("library"),
+		CodePath:       c.Args().Get(1),
+		SessionID:      c.String("session-id"),
+		SessionRoot:    c.String("session-root"),
+		CgroupRoot:     c.String("cgroup"),
+		Seccomp:        c.Bool("seccomp"),
+		CPUSupervisor:  c.Int("cpu-supervisor"),
+		IdlenessLimit:  c.Int("idleness-limit"),
+		MemoryStrategy: c.String("memory-strategy"),
+		Timeline:       c.Int("timeline"),
+		CPUCore:        c.Int("cpu"),
+		Parallelism:    c.Int("parallel"),
 	}
 
 	if persistenceOn {
 		rOptions.Persistence = &jOption
 	}
 
-	// 执行评测
-	_, judgeResult, err := StartJudgement(rOptions)
+	// 执行评测，收到SIGINT或SIGTERM时取消评测并清理所有进程
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	_, judgeResult, err := StartJudgementContext(ctx, rOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -144,6 +169,9 @@ func runUserJudge(c *cli.Context, configFile, workDir string) (*commonStructs.Ju
 	if !c.Bool("detail") {
 		judgeResult.TestCases = nil
 		judgeResult.JudgeLogs = nil
+	} else {
+		// 输出到stderr，不影响stdout上的JSON结果
+		printTimelines(os.Stderr, judgeResult)
 	}
 
 	return judgeResult, nil
@@ -1,6 +1,7 @@
 package run
 
 import (
+	"context"
 	"github.com/LanceLRQ/deer-executor/v2/common/persistence/result"
 	commonStructs "github.com/LanceLRQ/deer-executor/v2/common/structs"
 	"github.com/LanceLRQ/deer-executor/v2/executor"
@@ -8,7 +9,12 @@ import (
 
 // StartJudgement to run a judge work.
 func StartJudgement(options *JudgementRunOption) (*executor.JudgeSession, *commonStructs.JudgeResult, error) {
-	judgeResult, judgeSession, err := runOnceJudge(options)
+	return StartJudgementContext(context.Background(), options)
+}
+
+// StartJudgementContext to run a judge work which can be cancelled by ctx.
+func StartJudgementContext(ctx context.Context, options *JudgementRunOption) (*executor.JudgeSession, *commonStructs.JudgeResult, error) {
+	judgeResult, judgeSession, err := runOnceJudge(ctx, options)
 	if err != nil {
 		return nil, nil, err
 	}
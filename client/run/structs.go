@@ -4,15 +4,23 @@ import "github.com/LanceLRQ/deer-executor/v2/common/persistence"
 
 // JudgementRunOption options for StartJudgement
 type JudgementRunOption struct {
-	Persistence *persistence.JudgeResultPersisOptions
-	Clean       bool
-	ConfigFile  string
-	WorkDir     string
-	ShowLog     bool
-	LogLevel    int
-	Language    string
-	LibraryDir  string
-	CodePath    string
-	SessionID   string
-	SessionRoot string
+	Persistence    *persistence.JudgeResultPersisOptions
+	Clean          bool
+	ConfigFile     string
+	WorkDir        string
+	ShowLog        bool
+	LogLevel       int
+	Language       string
+	LibraryDir     string
+	CodePath       string
+	SessionID      string
+	SessionRoot    string
+	CgroupRoot     string
+	Seccomp        bool
+	CPUSupervisor  int
+	IdlenessLimit  int
+	MemoryStrategy string
+	Timeline       int
+	CPUCore        int
+	Parallelism    int
 }
@@ -0,0 +1,37 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package run
+
+import (
+	"fmt"
+	commonStructs "github.com/LanceLRQ/deer-executor/v2/common/structs"
+	"github.com/LanceLRQ/deer-executor/v2/common/utils"
+	"io"
+)
+
+// 迷你折线图的宽度（字符数）
+const sparklineWidth = 50
+
+// 把每个测试数据的资源使用时间线画成ASCII迷你折线图
+func printTimelines(w io.Writer, judgeResult *commonStructs.JudgeResult) {
+	for _, tc := range judgeResult.TestCases {
+		if len(tc.Timeline) == 0 {
+			continue
+		}
+		memory := make([]int, len(tc.Timeline))
+		cpu := make([]int, len(tc.Timeline))
+		peak := 0
+		for i, sample := range tc.Timeline {
+			memory[i] = sample.RSS
+			cpu[i] = sample.CPUTime
+			if sample.RSS > peak {
+				peak = sample.RSS
+			}
+		}
+		last := tc.Timeline[len(tc.Timeline)-1]
+		_, _ = fmt.Fprintf(w, "#%s %d samples in %dms\n", tc.Handle, len(tc.Timeline), last.Time)
+		_, _ = fmt.Fprintf(w, "  memory |%s| peak %dKB\n", utils.Sparkline(memory, sparklineWidth), peak)
+		_, _ = fmt.Fprintf(w, "  cpu    |%s| %dms\n", utils.Sparkline(cpu, sparklineWidth), last.CPUTime)
+	}
+}
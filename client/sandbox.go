@@ -0,0 +1,114 @@
+package client
+
+import (
+	"github.com/urfave/cli/v2"
+)
+
+// SandboxExecFlags for cli command 'sandbox exec'
+var SandboxExecFlags = []cli.Flag{
+	&cli.IntFlag{
+		Name:    "time",
+		Aliases: []string{"t"},
+		Value:   1000,
+		Usage:   "CPU time limit (ms), 0 means unlimited",
+	},
+	&cli.IntFlag{
+		Name:  "real-time",
+		Value: 0,
+		Usage: "real time limit (ms), 0 means unlimited",
+	},
+	&cli.IntFlag{
+		Name:    "memory",
+		Aliases: []string{"m"},
+		Value:   65535,
+		Usage:   "memory limit (KB), 0 means unlimited",
+	},
+	&cli.IntFlag{
+		Name:  "stack",
+		Value: 0,
+		Usage: "stack size limit (KB), 0 means twice the memory limit, -1 means unlimited",
+	},
+	&cli.IntFlag{
+		Name:  "fsize",
+		Value: 50 * 1024 * 1024,
+		Usage: "output file size limit (B), 0 means unlimited",
+	},
+	&cli.IntFlag{
+		Name:  "processes",
+		Value: 0,
+		Usage: "process/thread count limit, 0 means unlimited",
+	},
+	&cli.IntFlag{
+		Name:  "open-files",
+		Value: 0,
+		Usage: "open files limit, 0 means unlimited",
+	},
+	&cli.BoolFlag{
+		Name:  "core-dump",
+		Value: false,
+		Usage: "allow core dump",
+	},
+	&cli.StringFlag{
+		Name:  "stdin",
+		Value: "",
+		Usage: "redirect stdin from file",
+	},
+	&cli.StringFlag{
+		Name:  "stdout",
+		Value: "",
+		Usage: "redirect stdout to file",
+	},
+	&cli.StringFlag{
+		Name:  "stderr",
+		Value: "",
+		Usage: "redirect stderr to file",
+	},
+	&cli.StringSliceFlag{
+		Name:  "inherit-env",
+		Usage: "inherit environment variable from current process by name, can be repeated (default is an empty environment)",
+	},
+	&cli.StringSliceFlag{
+		Name:  "env",
+		Usage: "set environment variable (KEY=VALUE), can be repeated",
+	},
+	&cli.StringFlag{
+		Name:  "dir",
+		Value: "",
+		Usage: "working directory",
+	},
+	&cli.StringFlag{
+		Name:  "cgroup",
+		Value: "",
+		Usage: "enable cgroup v2 resource control with the given cgroup root (e.g. /sys/fs/cgroup/deer-executor)",
+	},
+	&cli.IntFlag{
+		Name:  "pids",
+		Value: 0,
+		Usage: "cgroup pids.max, only works with --cgroup, 0 means the value of --processes or default",
+	},
+	&cli.StringFlag{
+		Name:  "seccomp",
+		Value: "",
+		Usage: "seccomp profile name (strict|jvm|interpreter), empty means disabled (Linux only)",
+	},
+	&cli.IntFlag{
+		Name:  "cpu-supervisor",
+		Value: 0,
+		Usage: "polling interval (ms) of the CPU time supervisor, 0 means default (10ms), negative means disabled",
+	},
+	&cli.IntFlag{
+		Name:  "idleness-limit",
+		Value: 0,
+		Usage: "kill the program if its CPU time stays flat for this long (ms), 0 means default (3000ms, only if no real time limit), negative means disabled",
+	},
+	&cli.IntFlag{
+		Name:  "cpu",
+		Value: -1,
+		Usage: "pin the command to this CPU core (Linux only), -1 means not pinned",
+	},
+	&cli.StringFlag{
+		Name:  "memory-strategy",
+		Value: "",
+		Usage: "peak memory accounting: minflt, maxrss, vmhwm (sampling /proc, Linux only) or cgroup_peak, empty means cgroup_peak if cgroup is enabled, otherwise minflt",
+	},
+}
@@ -0,0 +1,153 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package sandbox
+
+import (
+	"context"
+	"github.com/LanceLRQ/deer-executor/v2/client"
+	"github.com/LanceLRQ/deer-executor/v2/common/constants"
+	commonSandbox "github.com/LanceLRQ/deer-executor/v2/common/sandbox"
+	"github.com/LanceLRQ/deer-executor/v2/common/sandbox/cgroup"
+	"github.com/LanceLRQ/deer-executor/v2/common/sandbox/forkexec"
+	"github.com/LanceLRQ/deer-executor/v2/common/sandbox/seccomp"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+	"os"
+	"strings"
+)
+
+// Exec 在沙箱里运行一个命令，并输出它的资源使用情况
+func Exec(c *cli.Context) error {
+	if c.Args().Len() < 1 {
+		err := errors.Errorf("no command")
+		client.NewClientErrorMessage(err, nil).Print(true)
+		return err
+	}
+	usage, err := execCommand(c)
+	if err != nil {
+		client.NewClientErrorMessage(err, nil).Print(true)
+		return err
+	}
+	client.NewClientSuccessMessage(usage).Print(true)
+	return nil
+}
+
+func execCommand(c *cli.Context) (*commonSandbox.Usage, error) {
+	files, err := openFiles(c.String("stdin"), c.String("stdout"), c.String("stderr"))
+	if err != nil {
+		return nil, err
+	}
+	defer closeFiles(files)
+
+	spec := commonSandbox.Spec{
+		Args:  c.Args().Slice(),
+		Env:   buildEnviron(c.StringSlice("inherit-env"), c.StringSlice("env")),
+		Dir:   c.String("dir"),
+		Files: files,
+		Limits: forkexec.ExecRLimit{
+			TimeLimit:      c.Int("time"),
+			RealTimeLimit:  c.Int("real-time"),
+			MemoryLimit:    c.Int("memory"),
+			StackLimit:     c.Int("stack"),
+			FileSizeLimit:  c.Int("fsize"),
+			ProcessLimit:   c.Int("processes"),
+			OpenFilesLimit: c.Int("open-files"),
+			CoreDump:       c.Bool("core-dump"),
+		},
+		ReportPeakTasks: true,
+	}
+	// 与评测时目标程序的设置保持一致
+	spec.SupervisorInterval = c.Int("cpu-supervisor")
+	if spec.SupervisorInterval == 0 {
+		spec.SupervisorInterval = constants.CPUSupervisorInterval
+	} else if spec.SupervisorInterval < 0 {
+		spec.SupervisorInterval = 0
+	}
+	spec.IdlenessLimit = c.Int("idleness-limit")
+	if spec.IdlenessLimit == 0 && spec.Limits.RealTimeLimit <= 0 {
+		spec.IdlenessLimit = constants.IdlenessLimit
+	} else if spec.IdlenessLimit < 0 {
+		spec.IdlenessLimit = 0
+	}
+	if core := c.Int("cpu"); core >= 0 {
+		spec.CPUs = []int{core}
+	}
+	spec.MemoryStrategy, err = commonSandbox.ParseMemoryStrategy(c.String("memory-strategy"))
+	if err != nil {
+		return nil, err
+	}
+	spec.Seccomp, err = seccomp.GetProfile(c.String("seccomp"))
+	if err != nil {
+		return nil, err
+	}
+	if root := c.String("cgroup"); root != "" {
+		spec.Cgroup = &commonSandbox.CgroupOptions{
+			Root:      root,
+			PidsLimit: c.Int("pids"),
+			CPULimit:  100,
+		}
+		if spec.Cgroup.PidsLimit <= 0 {
+			spec.Cgroup.PidsLimit = cgroup.DefaultPidsLimit
+			if spec.Limits.ProcessLimit > 0 {
+				spec.Cgroup.PidsLimit = spec.Limits.ProcessLimit
+			}
+		}
+	}
+	return commonSandbox.Run(context.Background(), spec)
+}
+
+// 打开重定向文件，未指定时继承当前进程的标准输入输出
+func openFiles(stdin, stdout, stderr string) ([]interface{}, error) {
+	files := []interface{}{os.Stdin, os.Stdout, os.Stderr}
+	if stdin != "" {
+		fin, err := os.OpenFile(stdin, os.O_RDONLY, 0)
+		if err != nil {
+			return nil, err
+		}
+		files[0] = fin
+	}
+	for i, name := range []string{stdout, stderr} {
+		if name == "" {
+			continue
+		}
+		fout, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			closeFiles(files)
+			return nil, err
+		}
+		files[i+1] = fout
+	}
+	return files, nil
+}
+
+// 关闭打开过的重定向文件
+func closeFiles(files []interface{}) {
+	for _, f := range files {
+		if fi, ok := f.(*os.File); ok && fi != os.Stdin && fi != os.Stdout && fi != os.Stderr {
+			_ = fi.Close()
+		}
+	}
+}
+
+// 与评测时的目标程序一样默认使用空环境，只继承白名单里的变量，再用KEY=VALUE形式的变量覆盖同名变量
+func buildEnviron(inherit []string, extra []string) []string {
+	base := make([]string, 0, len(inherit)+len(extra))
+	for _, k := range inherit {
+		if v, ok := os.LookupEnv(k); ok {
+			base = append(base, k+"="+v)
+		}
+	}
+	env := make([]string, 0, len(base)+len(extra))
+	index := map[string]int{}
+	for _, kv := range append(base, extra...) {
+		key := strings.SplitN(kv, "=", 2)[0]
+		if i, ok := index[key]; ok {
+			env[i] = kv
+			continue
+		}
+		index[key] = len(env)
+		env = append(env, kv)
+	}
+	return env
+}
@@ -0,0 +1,12 @@
+package sandbox
+
+import (
+	"fmt"
+	"github.com/urfave/cli/v2"
+)
+
+// Exec 在沙箱里运行一个命令
+func Exec(c *cli.Context) error {
+	fmt.Println("Sorry, sandbox only supporting linux/darwin")
+	return nil
+}
@@ -0,0 +1,101 @@
+// Package checker 内置的标准判题程序，参考testlib自带的checkers实现。
+// 它们在评测机的进程里运行，不需要编译，判题信息的格式与testlib保持一致
+package checker
+
+import (
+	"fmt"
+	"github.com/LanceLRQ/deer-executor/v2/common/constants"
+	"github.com/pkg/errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Result 判题结果
+type Result struct {
+	Verdict int    // 评测结果：AC、WA、PE，参考答案有误时为SpecialJudgeError
+	Message string // 判题信息
+	// 不包含选手输出和参考答案内容的判题信息，用于隐藏的测试数据；为空时与Message相同
+	Redacted string
+}
+
+// Checker 判题程序，读取测试数据的输入、选手的输出和参考答案
+type Checker func(input, output, answer io.Reader) Result
+
+var checkers = map[string]Checker{
+	"wcmp":  compareTokens,
+	"lcmp":  compareLines,
+	"ncmp":  compareIntegers,
+	"yesno": compareYesNo,
+	"uwcmp": compareTokenMultiset,
+}
+
+// Get 根据名称获取内置的判题程序
+// 支持wcmp（逐个比较单词）、lcmp（逐行比较单词）、ncmp（整数序列）、rcmpN（浮点数，绝对或相对误差不超过1e-N）、
+// yesno（不区分大小写的YES/NO序列）和uwcmp（不考虑顺序的单词多重集）
+func Get(name string) (Checker, error) {
+	if c, ok := checkers[name]; ok {
+		return c, nil
+	}
+	if strings.HasPrefix(name, "rcmp") {
+		digits, err := strconv.Atoi(strings.TrimPrefix(name, "rcmp"))
+		if err == nil && digits > 0 && digits <= 15 {
+			return compareDoubles(digits), nil
+		}
+	}
+	return nil, errors.Errorf("unknown builtin checker: %s", name)
+}
+
+func ok(format string, a ...interface{}) Result {
+	return Result{Verdict: constants.JudgeFlagAC, Message: fmt.Sprintf(format, a...)}
+}
+
+func wrongAnswer(format string, a ...interface{}) Result {
+	return Result{Verdict: constants.JudgeFlagWA, Message: fmt.Sprintf(format, a...)}
+}
+
+func presentationError(format string, a ...interface{}) Result {
+	return Result{Verdict: constants.JudgeFlagPE, Message: fmt.Sprintf(format, a...)}
+}
+
+func fail(format string, a ...interface{}) Result {
+	return Result{Verdict: constants.JudgeFlagSpecialJudgeError, Message: fmt.Sprintf(format, a...)}
+}
+
+// 设置不包含选手输出和参考答案内容的判题信息
+func (r Result) redact(format string, a ...interface{}) Result {
+	r.Redacted = fmt.Sprintf(format, a...)
+	return r
+}
+
+// 序数词的后缀，如1st、2nd、11th
+func englishEnding(n int) string {
+	if n/10%10 == 1 {
+		return "th"
+	}
+	switch n % 10 {
+	case 1:
+		return "st"
+	case 2:
+		return "nd"
+	case 3:
+		return "rd"
+	}
+	return "th"
+}
+
+// 过长的内容只保留开头和结尾
+func compress(s string) string {
+	if len(s) <= 64 {
+		return s
+	}
+	return s[:30] + "..." + s[len(s)-31:]
+}
+
+// 复数形式
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
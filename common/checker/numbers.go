@@ -0,0 +1,178 @@
+package checker
+
+import (
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ncmp: 比较整数序列
+func compareIntegers(_, output, answer io.Reader) Result {
+	ouf, ans := newTokenScanner(output), newTokenScanner(answer)
+	n := 0
+	var first string
+	for {
+		j, err := nextToken(ans)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return fail("answer: %s", err.Error())
+		}
+		expected, err := strconv.ParseInt(j, 10, 64)
+		if err != nil {
+			return fail("answer: expected integer, but \"%s\" found", compress(j)).redact("answer: expected integer")
+		}
+		n++
+		p, err := nextToken(ouf)
+		if err == io.EOF {
+			total := n
+			for _, e := nextToken(ans); e == nil; _, e = nextToken(ans) {
+				total++
+			}
+			return wrongAnswer("Answer contains longer sequence [length = %d], but output contains %d elements", total, n-1)
+		} else if err != nil {
+			return presentationError("%s", err.Error())
+		}
+		found, err := strconv.ParseInt(p, 10, 64)
+		if err != nil {
+			return presentationError("Expected integer, but \"%s\" found", compress(p)).redact("Expected integer")
+		}
+		if n <= 5 {
+			first += j + " "
+		}
+		if expected != found {
+			return wrongAnswer("%d%s numbers differ - expected: '%d', found: '%d'", n, englishEnding(n), expected, found).
+				redact("%d%s numbers differ", n, englishEnding(n))
+		}
+	}
+	extra := 0
+	for _, e := nextToken(ouf); e == nil; _, e = nextToken(ouf) {
+		extra++
+	}
+	if extra > 0 {
+		return wrongAnswer("Output contains longer sequence [length = %d], but answer contains %d elements", n+extra, n)
+	}
+	if n <= 5 {
+		return ok("%d %s(s): \"%s\"", n, "number", strings.TrimSpace(first)).redact("%d %s(s)", n, "number")
+	}
+	return ok("%d numbers", n)
+}
+
+// 与testlib的doubleCompare一致：绝对误差或者相对误差不超过eps
+func doubleCompare(expected, result, eps float64) bool {
+	if math.IsNaN(expected) {
+		return math.IsNaN(result)
+	}
+	if math.IsInf(expected, 0) {
+		return math.IsInf(result, 0) && (expected > 0) == (result > 0)
+	}
+	if math.IsNaN(result) || math.IsInf(result, 0) {
+		return false
+	}
+	if math.Abs(result-expected) <= eps+1e-15 {
+		return true
+	}
+	minv := math.Min(expected*(1.0-eps), expected*(1.0+eps))
+	maxv := math.Max(expected*(1.0-eps), expected*(1.0+eps))
+	return result+1e-15 >= minv && result <= maxv+1e-15
+}
+
+// 相对误差，参考答案为0时为绝对误差
+func doubleDelta(expected, result float64) float64 {
+	absolute := math.Abs(result - expected)
+	if math.Abs(expected) > 1e-9 {
+		return math.Min(absolute, absolute/math.Abs(expected))
+	}
+	return absolute
+}
+
+// rcmpN: 比较浮点数序列，绝对或相对误差不超过1e-N
+func compareDoubles(digits int) Checker {
+	eps := math.Pow(10, -float64(digits))
+	return func(_, output, answer io.Reader) Result {
+		ouf, ans := newTokenScanner(output), newTokenScanner(answer)
+		n := 0
+		for {
+			j, err := nextToken(ans)
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return fail("answer: %s", err.Error())
+			}
+			expected, err := strconv.ParseFloat(j, 64)
+			if err != nil {
+				return fail("answer: expected double, but \"%s\" found", compress(j)).redact("answer: expected double")
+			}
+			n++
+			p, err := nextToken(ouf)
+			if err == io.EOF {
+				return wrongAnswer("Unexpected EOF in the participants output: expected %d%s number", n, englishEnding(n))
+			} else if err != nil {
+				return presentationError("%s", err.Error())
+			}
+			found, err := strconv.ParseFloat(p, 64)
+			if err != nil {
+				return presentationError("Expected double, but \"%s\" found", compress(p)).redact("Expected double")
+			}
+			if !doubleCompare(expected, found, eps) {
+				return wrongAnswer("%d%s numbers differ - expected: '%.*f', found: '%.*f', error = '%.*f'",
+					n, englishEnding(n), digits, expected, digits, found, digits, doubleDelta(expected, found)).
+					redact("%d%s numbers differ - error = '%.*f'", n, englishEnding(n), digits, doubleDelta(expected, found))
+			}
+		}
+		extra := 0
+		for _, e := nextToken(ouf); e == nil; _, e = nextToken(ouf) {
+			extra++
+		}
+		if extra > 0 {
+			return wrongAnswer("Output contains longer sequence [length = %d], but answer contains %d elements", n+extra, n)
+		}
+		return ok("%d %s", n, plural(n, "number"))
+	}
+}
+
+// yesno: 比较YES/NO序列，不区分大小写
+func compareYesNo(_, output, answer io.Reader) Result {
+	ouf, ans := newTokenScanner(output), newTokenScanner(answer)
+	n, yes := 0, 0
+	for {
+		j, err := nextToken(ans)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return fail("answer: %s", err.Error())
+		}
+		j = strings.ToUpper(j)
+		if j != "YES" && j != "NO" {
+			return fail("answer: YES or NO expected, but %s found", compress(j)).redact("answer: YES or NO expected")
+		}
+		n++
+		p, err := nextToken(ouf)
+		if err == io.EOF {
+			return wrongAnswer("Unexpected EOF in the participants output: expected %d%s answer", n, englishEnding(n))
+		} else if err != nil {
+			return presentationError("%s", err.Error())
+		}
+		p = strings.ToUpper(p)
+		if p != "YES" && p != "NO" {
+			return presentationError("YES or NO expected, but %s found", compress(p)).redact("YES or NO expected")
+		}
+		if j != p {
+			if n == 1 {
+				return wrongAnswer("expected %s, found %s", j, p).redact("answer differs")
+			}
+			return wrongAnswer("%d%s answer: expected %s, found %s", n, englishEnding(n), j, p).redact("%d%s answer differs", n, englishEnding(n))
+		}
+		if j == "YES" {
+			yes++
+		}
+	}
+	if _, err := nextToken(ouf); err == nil {
+		return wrongAnswer("Output contains more answers than expected %d", n)
+	}
+	if n == 1 {
+		return ok("answer is %s", map[bool]string{true: "YES", false: "NO"}[yes == 1]).redact("single answer")
+	}
+	return ok("%d %s (%d yes, %d no)", n, plural(n, "answer"), yes, n-yes).redact("%d %s", n, plural(n, "answer"))
+}
@@ -0,0 +1,170 @@
+package checker
+
+import (
+	"bufio"
+	"github.com/pkg/errors"
+	"io"
+	"strings"
+)
+
+// 单个单词或者一行的最大长度
+const maxTokenSize = 64 * 1024 * 1024
+
+// 按空白字符切分单词
+func newTokenScanner(r io.Reader) *bufio.Scanner {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), maxTokenSize)
+	s.Split(bufio.ScanWords)
+	return s
+}
+
+// 读取下一个单词，读完时返回io.EOF
+func nextToken(s *bufio.Scanner) (string, error) {
+	if s.Scan() {
+		return s.Text(), nil
+	}
+	if err := s.Err(); err != nil {
+		return "", errors.Errorf("read error: %s", err.Error())
+	}
+	return "", io.EOF
+}
+
+// 读取所有的行，去掉行尾的\r和末尾的空行
+func readLines(r io.Reader) ([]string, error) {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), maxTokenSize)
+	lines := make([]string, 0)
+	for s.Scan() {
+		lines = append(lines, strings.TrimRight(s.Text(), "\r"))
+	}
+	if err := s.Err(); err != nil {
+		return nil, errors.Errorf("read error: %s", err.Error())
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines, nil
+}
+
+// wcmp: 逐个比较单词
+func compareTokens(_, output, answer io.Reader) Result {
+	ouf, ans := newTokenScanner(output), newTokenScanner(answer)
+	n := 0
+	var first string
+	for {
+		j, err := nextToken(ans)
+		if err != nil && err != io.EOF {
+			return fail("answer: %s", err.Error())
+		}
+		p, perr := nextToken(ouf)
+		if perr != nil && perr != io.EOF {
+			return presentationError("%s", perr.Error())
+		}
+		if err == io.EOF {
+			if perr == nil {
+				extra := 1
+				for _, e := nextToken(ouf); e == nil; _, e = nextToken(ouf) {
+					extra++
+				}
+				return wrongAnswer("Participant output contains longer sequence [length = %d], but answer contains %d elements", n+extra, n)
+			}
+			break
+		}
+		n++
+		if perr == io.EOF {
+			total := n
+			for _, e := nextToken(ans); e == nil; _, e = nextToken(ans) {
+				total++
+			}
+			return wrongAnswer("Answer contains longer sequence [length = %d], but output contains %d elements", total, n-1)
+		}
+		if n == 1 {
+			first = j
+		}
+		if j != p {
+			return wrongAnswer("%d%s words differ - expected: '%s', found: '%s'", n, englishEnding(n), compress(j), compress(p)).
+				redact("%d%s words differ", n, englishEnding(n))
+		}
+	}
+	if n == 1 {
+		return ok("single token: \"%s\"", compress(first)).redact("single token")
+	}
+	return ok("%d %s", n, plural(n, "token"))
+}
+
+// lcmp: 逐行比较，每一行按单词比较（忽略多余的空白字符）
+func compareLines(_, output, answer io.Reader) Result {
+	ansLines, err := readLines(answer)
+	if err != nil {
+		return fail("answer: %s", err.Error())
+	}
+	oufLines, err := readLines(output)
+	if err != nil {
+		return presentationError("%s", err.Error())
+	}
+	for i, j := range ansLines {
+		if i >= len(oufLines) {
+			return wrongAnswer("Answer contains longer sequence [length = %d], but output contains %d lines", len(ansLines), len(oufLines))
+		}
+		if strings.Join(strings.Fields(j), " ") != strings.Join(strings.Fields(oufLines[i]), " ") {
+			n := i + 1
+			return wrongAnswer("%d%s lines differ - expected: '%s', found: '%s'", n, englishEnding(n), compress(j), compress(oufLines[i])).
+				redact("%d%s lines differ", n, englishEnding(n))
+		}
+	}
+	if len(oufLines) > len(ansLines) {
+		return wrongAnswer("Participant output contains longer sequence [length = %d], but answer contains %d lines", len(oufLines), len(ansLines))
+	}
+	if len(ansLines) == 1 {
+		return ok("single line: '%s'", compress(ansLines[0])).redact("single line")
+	}
+	return ok("%d %s", len(ansLines), plural(len(ansLines), "line"))
+}
+
+// uwcmp: 比较两个单词多重集，不考虑顺序
+func compareTokenMultiset(_, output, answer io.Reader) Result {
+	count := map[string]int{}
+	order := make([]string, 0)
+	ans := newTokenScanner(answer)
+	n := 0
+	for {
+		j, err := nextToken(ans)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return fail("answer: %s", err.Error())
+		}
+		if count[j] == 0 {
+			order = append(order, j)
+		}
+		count[j]++
+		n++
+	}
+	ouf := newTokenScanner(output)
+	m := 0
+	for {
+		p, err := nextToken(ouf)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return presentationError("%s", err.Error())
+		}
+		m++
+		if m > n {
+			continue
+		}
+		if count[p] <= 0 {
+			return wrongAnswer("unexpected token '%s' found", compress(p)).redact("unexpected token found")
+		}
+		count[p]--
+	}
+	if m != n {
+		return wrongAnswer("expected %d %s, found %d", n, plural(n, "token"), m)
+	}
+	for _, j := range order {
+		if count[j] != 0 {
+			return wrongAnswer("token '%s' is missing", compress(j)).redact("token is missing")
+		}
+	}
+	return ok("%d %s", n, plural(n, "token"))
+}
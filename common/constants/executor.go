@@ -35,8 +35,61 @@ const (
 	JudgeFlagSpecialJudgeError = 11
 	// Special Judge Checker Finish, Need Standard Checkup
 	JudgeFlagSpecialJudgeRequireChecker = 12
+	// Restricted Function
+	JudgeFlagRF = 13
+	// Idleness Limit Exceeded
+	JudgeFlagILE = 14
+	// Judgement Cancelled
+	JudgeFlagCancelled = 15
 )
 
+// Subtask scoring policies
+const (
+	SubtaskPolicyAll = "all" // All-or-nothing (default)
+	SubtaskPolicyMin = "min" // Score * minimum case score
+	SubtaskPolicySum = "sum" // Score * average case score
+)
+
+// Judge policies
+const (
+	JudgePolicyStop   = "stop"    // Stop at the first failed case
+	JudgePolicyRunAll = "run_all" // Keep judging after failures
+
+	JudgePolicyPEAccept   = "accept"   // PE is accepted
+	JudgePolicyPEFail     = "fail"     // PE is a failure
+	JudgePolicyPEContinue = "continue" // PE is a failure but never stops judging
+
+	JudgePolicyVerdictWorst = "worst" // The most severe verdict
+	JudgePolicyVerdictFirst = "first" // The verdict of the first failed case
+)
+
+// Text compare modes
+const (
+	CompareModeText  = "text"  // Compare each char (default)
+	CompareModeFloat = "float" // Compare tokens, numbers with epsilon
+
+	CompareDefaultEpsilon = 1e-6 // Default epsilon of float mode
+)
+
+// JudgeFlagSeverity 计算最终结果时各评测结果的严重程度，越大越严重
+var JudgeFlagSeverity = map[int]int{
+	JudgeFlagAC:                         0,
+	JudgeFlagPE:                         1,
+	JudgeFlagWA:                         2,
+	JudgeFlagOLE:                        3,
+	JudgeFlagILE:                        4,
+	JudgeFlagTLE:                        5,
+	JudgeFlagMLE:                        6,
+	JudgeFlagRE:                         7,
+	JudgeFlagRF:                         8,
+	JudgeFlagSpecialJudgeRequireChecker: 9,
+	JudgeFlagSpecialJudgeTimeout:        10,
+	JudgeFlagSpecialJudgeError:          11,
+	JudgeFlagCE:                         12,
+	JudgeFlagSE:                         13,
+	JudgeFlagCancelled:                  14,
+}
+
 // Special Judge Mode
 const (
 	SpecialJudgeModeDisabled    = 0
@@ -49,6 +102,41 @@ const (
 	SpecialJudgeMemoryLimit = 256 * 1024
 )
 
+// Time limit killers, who killed the program when it exceeded the time limit
+const (
+	TimeLimitKillerSupervisor = "supervisor" // CPU time supervisor (exact millisecond limit)
+	TimeLimitKillerRLimit     = "rlimit"     // RLIMIT_CPU (rounded up to whole seconds)
+	TimeLimitKillerRealTime   = "real_time"  // Real time limit (SIGALRM or real time watchdog)
+
+	// unit: ms
+	CPUSupervisorInterval = 10
+	// unit: ms
+	IdlenessLimit = 3000
+	// unit: ms
+	TaskMonitorInterval = 50
+	// unit: ms
+	MemorySamplerInterval = 10
+	// 资源使用时间线默认最多保留的采样点数
+	TimelineMaxSamples = 100
+)
+
+// ProcessLimit 默认的进程/线程数限制
+const ProcessLimit = 16
+
+// ProcessLimitForLanguage 给带虚拟机或者多线程运行时的语言设定更高的进程/线程数限制
+var ProcessLimitForLanguage = map[string]int{
+	"gcc":     16,
+	"g++":     16,
+	"java":    256, // JVM的GC、JIT编译线程
+	"python2": 16,
+	"python3": 16,
+	"nodejs":  64, // V8和libuv的线程池
+	"golang":  64, // Go运行时的线程
+	"php":     16,
+	"ruby":    16,
+	"rust":    16,
+}
+
 // SignalNumberMap  map unix signal to text
 var SignalNumberMap = map[int][]string{
 	1: {"SIGHUP", "Hangup (POSIX)."},
@@ -99,6 +187,9 @@ var FlagMeansMap = map[int]string{
 	9:  "Special Judge Checker Time OUT",
 	10: "Special Judge Checker ERROR",
 	11: "Special Judge Checker Finish, Need Standard Checkup",
+	13: "Restricted Function",
+	14: "Idleness Limit Exceeded",
+	15: "Cancelled",
 }
 
 // MemorySizeForJIT 给动态语言、带虚拟机的语言设定虚拟机自身的初始内存大小
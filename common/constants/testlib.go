@@ -18,7 +18,7 @@ var TestlibExitMsgMapping = []struct {
 	{ErrName: "wrong answer", JudgeResult: JudgeFlagWA},
 	{ErrName: "wrong output format", JudgeResult: JudgeFlagPE},
 	{ErrName: "FAIL", JudgeResult: JudgeFlagSpecialJudgeError},
-	{ErrName: "points", JudgeResult: JudgeFlagSpecialJudgeError}, // Unsupport
+	{ErrName: "points", JudgeResult: JudgeFlagWA, WithScore: true},
 	{ErrName: "unexpected eof", JudgeResult: JudgeFlagPE},
 	{ErrName: "partially correct", JudgeResult: JudgeFlagWA, WithScore: true},
 	{ErrName: "What is the code", JudgeResult: JudgeFlagSpecialJudgeError},
@@ -30,7 +30,7 @@ var TestlibOutcomeMapping = map[string]int{
 	"wrong-answer":       JudgeFlagWA,
 	"presentation-error": JudgeFlagPE,
 	"fail":               JudgeFlagSpecialJudgeError,
-	"points":             JudgeFlagSpecialJudgeError, // Unsupport
+	"points":             JudgeFlagWA,                // 按points属性给部分分
 	"relative-scoring":   JudgeFlagSpecialJudgeError, // Unsupport
 	"unexpected-eof":     JudgeFlagPE,
 	"partially-correct":  JudgeFlagWA,
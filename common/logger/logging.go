@@ -2,6 +2,7 @@ package logger
 
 import (
 	"fmt"
+	"sync"
 	"time"
 )
 
@@ -33,8 +34,9 @@ var LogLevelStrMapping = map[string]int{
 	"error": LogLevelError,
 }
 
-// JudgeLogger 评测日志
+// JudgeLogger 评测日志（并行运行测试数据时会被多个协程同时写入）
 type JudgeLogger struct {
+	mu sync.Mutex
 	// 日志数据
 	logs []JudgeLogItem
 	// T-0时间
@@ -54,6 +56,8 @@ func NewJudgeLogger() *JudgeLogger {
 
 // Log 输出Log的基础函数
 func (logger *JudgeLogger) Log(level int, msg string) {
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
 	nowTime := time.Now()
 	if len(logger.logs) <= 0 {
 		// 如果还没有写入过日志，则以这个时间作为起点
@@ -123,6 +127,8 @@ func (logger *JudgeLogger) Errorf(msg string, args ...interface{}) {
 
 // GetLogs 获取当前的日志列表
 func (logger *JudgeLogger) GetLogs() []JudgeLogItem {
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
 	return logger.logs
 }
 
@@ -2,7 +2,11 @@ package provider
 
 // GCC Compiler Provider
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"github.com/LanceLRQ/deer-executor/v2/common/sandbox/seccomp"
+)
 
 // GnucCompileProvider c语言编译提供程序
 type GnucCompileProvider struct {
@@ -18,9 +22,10 @@ type GnucppCompileProvider struct {
 func NewGnucCompileProvider() *GnucCompileProvider {
 	return &GnucCompileProvider{
 		CodeCompileProvider{
-			isReady:  false,
-			realTime: false,
-			Name:     "gcc",
+			isReady:        false,
+			realTime:       false,
+			Name:           "gcc",
+			seccompProfile: seccomp.ProfileStrict,
 		},
 	}
 }
@@ -40,8 +45,8 @@ func (prov *GnucCompileProvider) Init(code string, workDir string) error {
 }
 
 // Compile 编译程序
-func (prov *GnucCompileProvider) Compile() (result bool, errmsg string) {
-	result, errmsg = prov.shell(fmt.Sprintf(CompileCommands.GNUC, prov.codeFilePath, prov.programFilePath))
+func (prov *GnucCompileProvider) Compile(ctx context.Context) (result bool, errmsg string) {
+	result, errmsg = prov.shell(ctx, fmt.Sprintf(CompileCommands.GNUC, prov.codeFilePath, prov.programFilePath))
 	if result {
 		prov.isReady = true
 	}
@@ -60,14 +65,14 @@ func (prov *GnucCompileProvider) IsCompileError(remsg string) bool {
 }
 
 // ManualCompile 执行手动编译
-func (prov *GnucCompileProvider) ManualCompile(source string, target string, libraryDir []string) (bool, string) {
+func (prov *GnucCompileProvider) ManualCompile(ctx context.Context, source string, target string, libraryDir []string) (bool, string) {
 	cmd := fmt.Sprintf(CompileCommands.GNUC, source, target)
 	if libraryDir != nil {
 		for _, v := range libraryDir {
 			cmd += fmt.Sprintf(" -I %s", v)
 		}
 	}
-	result, err := prov.shell(cmd)
+	result, err := prov.shell(ctx, cmd)
 	return result, err
 }
 
@@ -75,9 +80,10 @@ func (prov *GnucCompileProvider) ManualCompile(source string, target string, lib
 func NewGnucppCompileProvider() *GnucppCompileProvider {
 	return &GnucppCompileProvider{
 		CodeCompileProvider{
-			isReady:  false,
-			realTime: false,
-			Name:     "g++",
+			isReady:        false,
+			realTime:       false,
+			Name:           "g++",
+			seccompProfile: seccomp.ProfileStrict,
 		},
 	}
 }
@@ -97,8 +103,8 @@ func (prov *GnucppCompileProvider) Init(code string, workDir string) error {
 }
 
 // Compile 编译程序
-func (prov *GnucppCompileProvider) Compile() (result bool, errmsg string) {
-	result, errmsg = prov.shell(fmt.Sprintf(CompileCommands.GNUCPP, prov.codeFilePath, prov.programFilePath))
+func (prov *GnucppCompileProvider) Compile(ctx context.Context) (result bool, errmsg string) {
+	result, errmsg = prov.shell(ctx, fmt.Sprintf(CompileCommands.GNUCPP, prov.codeFilePath, prov.programFilePath))
 	if result {
 		prov.isReady = true
 	}
@@ -117,13 +123,13 @@ func (prov *GnucppCompileProvider) IsCompileError(remsg string) bool {
 }
 
 // ManualCompile 执行手动编译
-func (prov *GnucppCompileProvider) ManualCompile(source string, target string, libraryDir []string) (bool, string) {
+func (prov *GnucppCompileProvider) ManualCompile(ctx context.Context, source string, target string, libraryDir []string) (bool, string) {
 	cmd := fmt.Sprintf(CompileCommands.GNUCPP, source, target)
 	if libraryDir != nil {
 		for _, v := range libraryDir {
 			cmd += fmt.Sprintf(" -I %s", v)
 		}
 	}
-	result, err := prov.shell(cmd)
+	result, err := prov.shell(ctx, cmd)
 	return result, err
 }
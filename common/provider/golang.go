@@ -2,7 +2,11 @@ package provider
 
 // Golang Compiler Provider
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"github.com/LanceLRQ/deer-executor/v2/common/sandbox/seccomp"
+)
 
 // GolangCompileProvider go语言编译提供程序
 type GolangCompileProvider struct {
@@ -13,9 +17,10 @@ type GolangCompileProvider struct {
 func NewGolangCompileProvider() *GolangCompileProvider {
 	return &GolangCompileProvider{
 		CodeCompileProvider{
-			isReady:  false,
-			realTime: false,
-			Name:     "golang",
+			isReady:        false,
+			realTime:       false,
+			Name:           "golang",
+			seccompProfile: seccomp.ProfileJVM,
 		},
 	}
 }
@@ -35,8 +40,8 @@ func (prov *GolangCompileProvider) Init(code string, workDir string) error {
 }
 
 // Compile 编译程序
-func (prov *GolangCompileProvider) Compile() (result bool, errmsg string) {
-	result, errmsg = prov.shell(fmt.Sprintf(CompileCommands.Go, prov.programFilePath, prov.codeFilePath))
+func (prov *GolangCompileProvider) Compile(ctx context.Context) (result bool, errmsg string) {
+	result, errmsg = prov.shell(ctx, fmt.Sprintf(CompileCommands.Go, prov.programFilePath, prov.codeFilePath))
 	if result {
 		prov.isReady = true
 	}
@@ -55,8 +60,8 @@ func (prov *GolangCompileProvider) IsCompileError(remsg string) bool {
 }
 
 // ManualCompile 执行手动编译
-func (prov *GolangCompileProvider) ManualCompile(source string, target string) (bool, string) {
+func (prov *GolangCompileProvider) ManualCompile(ctx context.Context, source string, target string) (bool, string) {
 	cmd := fmt.Sprintf(CompileCommands.Go, source, target)
-	result, err := prov.shell(cmd)
+	result, err := prov.shell(ctx, cmd)
 	return result, err
 }
@@ -3,7 +3,9 @@ package provider
 // Java Compiler Provider
 
 import (
+	"context"
 	"fmt"
+	"github.com/LanceLRQ/deer-executor/v2/common/sandbox/seccomp"
 	"path"
 	"regexp"
 )
@@ -22,6 +24,7 @@ func NewJavaCompileProvider() *JavaCompileProvider {
 	java.isReady = false
 	java.realTime = false
 	java.Name = "java"
+	java.seccompProfile = seccomp.ProfileJVM
 	return &java
 }
 
@@ -71,8 +74,8 @@ func (prov *JavaCompileProvider) initFiles(codeExt string, programExt string) er
 }
 
 // Compile 编译程序
-func (prov *JavaCompileProvider) Compile() (result bool, errmsg string) {
-	result, errmsg = prov.shell(fmt.Sprintf(CompileCommands.Java, prov.codeFilePath, path.Dir(prov.programFilePath)))
+func (prov *JavaCompileProvider) Compile(ctx context.Context) (result bool, errmsg string) {
+	result, errmsg = prov.shell(ctx, fmt.Sprintf(CompileCommands.Java, prov.codeFilePath, path.Dir(prov.programFilePath)))
 	if result {
 		prov.isReady = true
 	}
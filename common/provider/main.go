@@ -52,7 +52,7 @@ type CodeCompileProviderInterface interface {
 	// 初始化文件信息
 	initFiles(codeExt string, programExt string) error
 	// 执行编译
-	Compile() (result bool, errmsg string)
+	Compile(ctx context.Context) (result bool, errmsg string)
 	// 清理工作目录
 	Clean()
 	// 获取程序的运行命令参数组
@@ -64,25 +64,31 @@ type CodeCompileProviderInterface interface {
 	// 是否已经编译完毕
 	IsReady() bool
 	// 调用Shell命令并获取运行结果
-	shell(commands string) (success bool, errout string)
+	shell(ctx context.Context, commands string) (success bool, errout string)
 	// 保存代码到文件
 	saveCode() error
 	// 检查工作目录是否存在
 	checkWorkDir() error
 	// 获取提供程序的名称
 	GetName() string
+	// 获取运行目标程序时使用的系统调用过滤规则
+	GetSeccompProfile() string
+	// 获取运行目标程序时使用的环境变量策略
+	GetEnvironmentPolicy() structs.EnvironmentPolicy
 }
 
 // CodeCompileProvider 代码编译提供程序公共结构定义
 type CodeCompileProvider struct {
 	CodeCompileProviderInterface
-	Name                             string // 编译器提供程序名称
-	codeContent                      string // 代码
-	realTime                         bool   // 是否为实时编译的语言
-	isReady                          bool   // 是否已经编译完毕
-	codeFileName, codeFilePath       string // 目标程序源文件
-	programFileName, programFilePath string // 目标程序文件
-	workDir                          string // 工作目录
+	Name                             string                    // 编译器提供程序名称
+	codeContent                      string                    // 代码
+	realTime                         bool                      // 是否为实时编译的语言
+	isReady                          bool                      // 是否已经编译完毕
+	codeFileName, codeFilePath       string                    // 目标程序源文件
+	programFileName, programFilePath string                    // 目标程序文件
+	workDir                          string                    // 工作目录
+	seccompProfile                   string                    // 系统调用过滤规则名称
+	environ                          structs.EnvironmentPolicy // 环境变量策略
 }
 
 // PlaceCompilerCommands 替换编译命令集
@@ -121,6 +127,16 @@ func (prov *CodeCompileProvider) GetName() string {
 	return prov.Name
 }
 
+// GetSeccompProfile 获取运行目标程序时使用的系统调用过滤规则
+func (prov *CodeCompileProvider) GetSeccompProfile() string {
+	return prov.seccompProfile
+}
+
+// GetEnvironmentPolicy 获取运行目标程序时使用的环境变量策略
+func (prov *CodeCompileProvider) GetEnvironmentPolicy() structs.EnvironmentPolicy {
+	return prov.environ
+}
+
 // Clean 清理代码
 func (prov *CodeCompileProvider) Clean() {
 	_ = os.Remove(prov.codeFilePath)
@@ -128,8 +144,10 @@ func (prov *CodeCompileProvider) Clean() {
 }
 
 // 执行shell
-func (prov *CodeCompileProvider) shell(commands string) (success bool, errout string) {
-	ctx, _ := context.WithTimeout(context.Background(), 7*time.Second)
+// ctx被取消或超时的时候杀死编译器
+func (prov *CodeCompileProvider) shell(ctx context.Context, commands string) (success bool, errout string) {
+	ctx, cancel := context.WithTimeout(ctx, 7*time.Second)
+	defer cancel()
 	cmdArgs := strings.Split(commands, " ")
 	if len(cmdArgs) <= 1 {
 		return false, "not enough arguments for compiler"
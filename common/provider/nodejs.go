@@ -3,7 +3,9 @@ package provider
 // NodeJS Compiler Provider
 
 import (
+	"context"
 	"fmt"
+	"github.com/LanceLRQ/deer-executor/v2/common/sandbox/seccomp"
 	"strings"
 )
 
@@ -16,9 +18,10 @@ type NodeJSCompileProvider struct {
 func NewNodeJSCompileProvider() *NodeJSCompileProvider {
 	return &NodeJSCompileProvider{
 		CodeCompileProvider{
-			isReady:  false,
-			realTime: true,
-			Name:     "nodejs",
+			isReady:        false,
+			realTime:       true,
+			Name:           "nodejs",
+			seccompProfile: seccomp.ProfileInterpreter,
 		},
 	}
 }
@@ -38,8 +41,8 @@ func (prov *NodeJSCompileProvider) Init(code string, workDir string) error {
 }
 
 // Compile 编译程序
-func (prov *NodeJSCompileProvider) Compile() (result bool, errmsg string) {
-	result, errmsg = prov.shell(fmt.Sprintf(CompileCommands.NodeJS, prov.codeFilePath))
+func (prov *NodeJSCompileProvider) Compile(ctx context.Context) (result bool, errmsg string) {
+	result, errmsg = prov.shell(ctx, fmt.Sprintf(CompileCommands.NodeJS, prov.codeFilePath))
 	if result {
 		prov.isReady = true
 	}
@@ -3,7 +3,9 @@ package provider
 // PHP Compiler Provider
 
 import (
+	"context"
 	"fmt"
+	"github.com/LanceLRQ/deer-executor/v2/common/sandbox/seccomp"
 )
 
 // PHPCompileProvider php语言编译提供程序
@@ -15,9 +17,10 @@ type PHPCompileProvider struct {
 func NewPHPCompileProvider() *PHPCompileProvider {
 	return &PHPCompileProvider{
 		CodeCompileProvider{
-			isReady:  false,
-			realTime: true,
-			Name:     "php",
+			isReady:        false,
+			realTime:       true,
+			Name:           "php",
+			seccompProfile: seccomp.ProfileInterpreter,
 		},
 	}
 }
@@ -37,8 +40,8 @@ func (prov *PHPCompileProvider) Init(code string, workDir string) error {
 }
 
 // Compile 编译程序
-func (prov *PHPCompileProvider) Compile() (result bool, errmsg string) {
-	result, errmsg = prov.shell(fmt.Sprintf(CompileCommands.PHP, prov.codeFilePath))
+func (prov *PHPCompileProvider) Compile(ctx context.Context) (result bool, errmsg string) {
+	result, errmsg = prov.shell(ctx, fmt.Sprintf(CompileCommands.PHP, prov.codeFilePath))
 	if result {
 		prov.isReady = true
 	}
@@ -3,10 +3,18 @@ package provider
 // Python Compiler Provider
 
 import (
+	"context"
 	"fmt"
+	"github.com/LanceLRQ/deer-executor/v2/common/sandbox/seccomp"
+	"github.com/LanceLRQ/deer-executor/v2/common/structs"
 	"strings"
 )
 
+// python的标准输入输出默认使用系统编码，空环境下需要指定为utf-8
+var pythonEnvironmentPolicy = structs.EnvironmentPolicy{
+	Fixed: map[string]string{"PYTHONIOENCODING": "utf-8"},
+}
+
 // Py2CompileProvider python2语言编译提供程序
 type Py2CompileProvider struct {
 	CodeCompileProvider
@@ -21,9 +29,11 @@ type Py3CompileProvider struct {
 func NewPy2CompileProvider() *Py2CompileProvider {
 	return &Py2CompileProvider{
 		CodeCompileProvider{
-			isReady:  false,
-			realTime: true,
-			Name:     "python2",
+			isReady:        false,
+			realTime:       true,
+			Name:           "python2",
+			seccompProfile: seccomp.ProfileInterpreter,
+			environ:        pythonEnvironmentPolicy,
 		},
 	}
 }
@@ -49,7 +59,7 @@ func (prov *Py2CompileProvider) Init(code string, workDir string) error {
 }
 
 // Compile 编译程序
-func (prov *Py2CompileProvider) Compile() (result bool, errmsg string) {
+func (prov *Py2CompileProvider) Compile(ctx context.Context) (result bool, errmsg string) {
 	return true, ""
 }
 
@@ -71,9 +81,11 @@ func (prov *Py2CompileProvider) IsCompileError(remsg string) bool {
 func NewPy3CompileProvider() *Py3CompileProvider {
 	return &Py3CompileProvider{
 		CodeCompileProvider{
-			isReady:  false,
-			realTime: true,
-			Name:     "python3",
+			isReady:        false,
+			realTime:       true,
+			Name:           "python3",
+			seccompProfile: seccomp.ProfileInterpreter,
+			environ:        pythonEnvironmentPolicy,
 		},
 	}
 }
@@ -97,7 +109,7 @@ func (prov *Py3CompileProvider) Init(code string, workDir string) error {
 }
 
 // Compile 编译程序
-func (prov *Py3CompileProvider) Compile() (result bool, errmsg string) {
+func (prov *Py3CompileProvider) Compile(ctx context.Context) (result bool, errmsg string) {
 	return true, ""
 }
 
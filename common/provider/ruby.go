@@ -3,7 +3,9 @@ package provider
 // Ruby Compiler Provider
 
 import (
+	"context"
 	"fmt"
+	"github.com/LanceLRQ/deer-executor/v2/common/sandbox/seccomp"
 )
 
 // RubyCompileProvider ruby语言编译提供程序
@@ -15,9 +17,10 @@ type RubyCompileProvider struct {
 func NewRubyCompileProvider() *RubyCompileProvider {
 	return &RubyCompileProvider{
 		CodeCompileProvider{
-			isReady:  false,
-			realTime: true,
-			Name:     "ruby",
+			isReady:        false,
+			realTime:       true,
+			Name:           "ruby",
+			seccompProfile: seccomp.ProfileInterpreter,
 		},
 	}
 }
@@ -37,8 +40,8 @@ func (prov *RubyCompileProvider) Init(code string, workDir string) error {
 }
 
 // Compile 编译程序
-func (prov *RubyCompileProvider) Compile() (result bool, errmsg string) {
-	result, errmsg = prov.shell(fmt.Sprintf(CompileCommands.Ruby, prov.codeFilePath))
+func (prov *RubyCompileProvider) Compile(ctx context.Context) (result bool, errmsg string) {
+	result, errmsg = prov.shell(ctx, fmt.Sprintf(CompileCommands.Ruby, prov.codeFilePath))
 	if result {
 		prov.isReady = true
 	}
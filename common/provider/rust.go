@@ -1,6 +1,10 @@
 package provider
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"github.com/LanceLRQ/deer-executor/v2/common/sandbox/seccomp"
+)
 
 // RustCompileProvider rust语言编译提供程序
 type RustCompileProvider struct {
@@ -11,9 +15,10 @@ type RustCompileProvider struct {
 func NewRustCompileProvider() *RustCompileProvider {
 	return &RustCompileProvider{
 		CodeCompileProvider{
-			isReady:  false,
-			realTime: false,
-			Name:     "rust",
+			isReady:        false,
+			realTime:       false,
+			Name:           "rust",
+			seccompProfile: seccomp.ProfileStrict,
 		},
 	}
 }
@@ -33,8 +38,8 @@ func (prov *RustCompileProvider) Init(code string, workDir string) error {
 }
 
 // Compile 编译程序
-func (prov *RustCompileProvider) Compile() (result bool, errmsg string) {
-	result, errmsg = prov.shell(fmt.Sprintf(CompileCommands.Rust, prov.codeFilePath, prov.programFilePath))
+func (prov *RustCompileProvider) Compile(ctx context.Context) (result bool, errmsg string) {
+	result, errmsg = prov.shell(ctx, fmt.Sprintf(CompileCommands.Rust, prov.codeFilePath, prov.programFilePath))
 	if result {
 		prov.isReady = true
 	}
@@ -0,0 +1,31 @@
+package cgroup
+
+import "github.com/pkg/errors"
+
+// DefaultRoot 默认的cgroup v2根目录（评测进程的cgroup都会创建在它下面）
+const DefaultRoot = "/sys/fs/cgroup/deer-executor"
+
+// DefaultPidsLimit 默认的进程/线程数限制
+const DefaultPidsLimit = 128
+
+// cpu.max 的调度周期 (us)
+const cpuPeriod = 100000
+
+// ErrNotSupported 当前平台不支持cgroup v2
+var ErrNotSupported = errors.New("cgroup v2 is not supported on this platform")
+
+// Limits cgroup资源限制
+type Limits struct {
+	MemoryLimit int // 内存限制 (KB)，写入memory.max，0表示不限制
+	PidsLimit   int // 进程/线程数限制，写入pids.max，0表示不限制
+	CPULimit    int // CPU带宽限制 (百分比，100表示一个核心)，写入cpu.max，0表示不限制
+}
+
+// Stat cgroup资源统计信息
+type Stat struct {
+	CPUTime     int  `json:"cpu_time"`     // CPU时间 (ms)，来自cpu.stat的usage_usec
+	MemoryPeak  int  `json:"memory_peak"`  // 内存峰值 (KB)，来自memory.peak，内核不支持时为0
+	OOMKilled   bool `json:"oom_killed"`   // 是否因超出memory.max被OOM Killer杀死，来自memory.events
+	PidsPeak    int  `json:"pids_peak"`    // 进程/线程数峰值，来自pids.peak，内核不支持时为0
+	PidsLimited bool `json:"pids_limited"` // 是否有fork/clone因超出pids.max被拒绝，来自pids.events
+}
@@ -0,0 +1,247 @@
+//go:build linux
+// +build linux
+
+package cgroup
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/LanceLRQ/deer-executor/v2/common/sandbox/forkexec"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// 评测需要用到的控制器
+var requiredControllers = []string{"cpu", "memory", "pids"}
+
+// 已经初始化过的cgroup根目录
+var preparedRoots = map[string]bool{}
+var preparedRootsLock sync.Mutex
+
+// Cgroup 一个评测进程专用的临时cgroup
+type Cgroup struct {
+	Path      string   // cgroup目录
+	procsFile *os.File // cgroup.procs，子进程在exec前往里写入自身的pid
+}
+
+// 初始化根目录，并在subtree_control里开启评测需要的控制器
+func prepareRoot(root string) error {
+	preparedRootsLock.Lock()
+	defer preparedRootsLock.Unlock()
+	if preparedRoots[root] {
+		return nil
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return errors.Errorf("create cgroup root error: %s", err.Error())
+	}
+	controllers, err := ioutil.ReadFile(path.Join(root, "cgroup.controllers"))
+	if err != nil {
+		return errors.Errorf("cgroup root (%s) is not a cgroup v2 directory", root)
+	}
+	available := strings.Fields(string(controllers))
+	for _, name := range requiredControllers {
+		found := false
+		for _, c := range available {
+			if c == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.Errorf("cgroup controller (%s) is not available in %s", name, root)
+		}
+		err = ioutil.WriteFile(path.Join(root, "cgroup.subtree_control"), []byte("+"+name), 0644)
+		if err != nil {
+			return errors.Errorf("enable cgroup controller (%s) error: %s", name, err.Error())
+		}
+	}
+	preparedRoots[root] = true
+	return nil
+}
+
+// New 在root下创建一个名为name的cgroup并设置资源限制
+func New(root, name string, limits Limits) (*Cgroup, error) {
+	if err := prepareRoot(root); err != nil {
+		return nil, err
+	}
+	cg := &Cgroup{Path: path.Join(root, name)}
+	if err := os.Mkdir(cg.Path, 0755); err != nil {
+		return nil, errors.Errorf("create cgroup error: %s", err.Error())
+	}
+	if limits.MemoryLimit > 0 {
+		if err := cg.write("memory.max", strconv.Itoa(limits.MemoryLimit*1024)); err != nil {
+			_ = cg.Destroy()
+			return nil, err
+		}
+		// 禁止使用swap绕过内存限制（没有开启swap记账的内核没有这个文件）
+		if _, err := os.Stat(path.Join(cg.Path, "memory.swap.max")); err == nil {
+			_ = cg.write("memory.swap.max", "0")
+		}
+	}
+	if limits.PidsLimit > 0 {
+		if err := cg.write("pids.max", strconv.Itoa(limits.PidsLimit)); err != nil {
+			_ = cg.Destroy()
+			return nil, err
+		}
+	}
+	if limits.CPULimit > 0 {
+		quota := fmt.Sprintf("%d %d", cpuPeriod*limits.CPULimit/100, cpuPeriod)
+		if err := cg.write("cpu.max", quota); err != nil {
+			_ = cg.Destroy()
+			return nil, err
+		}
+	}
+	procs, err := os.OpenFile(path.Join(cg.Path, "cgroup.procs"), os.O_WRONLY, 0)
+	if err != nil {
+		_ = cg.Destroy()
+		return nil, errors.Errorf("open cgroup.procs error: %s", err.Error())
+	}
+	cg.procsFile = procs
+	return cg, nil
+}
+
+// Apply 让即将启动的子进程在exec前加入这个cgroup
+func (cg *Cgroup) Apply(sys *forkexec.SysProcAttr) {
+	sys.UseCgroupFD = true
+	sys.CgroupFD = int(cg.procsFile.Fd())
+}
+
+// Stat 读取cgroup的资源统计信息
+func (cg *Cgroup) Stat() (*Stat, error) {
+	stat := Stat{}
+	cpuTime, err := cg.CPUTime()
+	if err != nil {
+		return nil, err
+	}
+	stat.CPUTime = cpuTime
+	// memory.peak 在 5.19 以后的内核才有
+	if peak, err := ioutil.ReadFile(path.Join(cg.Path, "memory.peak")); err == nil {
+		if value, err := strconv.ParseInt(strings.TrimSpace(string(peak)), 10, 64); err == nil {
+			stat.MemoryPeak = int(value / 1024)
+		}
+	}
+	// pids.peak 在 6.13 以后的内核才有
+	if peak, err := ioutil.ReadFile(path.Join(cg.Path, "pids.peak")); err == nil {
+		stat.PidsPeak, _ = strconv.Atoi(strings.TrimSpace(string(peak)))
+	}
+	events, err := cg.readKeyValues("memory.events")
+	if err != nil {
+		return nil, err
+	}
+	stat.OOMKilled = events["oom_kill"] > 0
+	if events, err := cg.readKeyValues("pids.events"); err == nil {
+		stat.PidsLimited = events["max"] > 0
+	}
+	return &stat, nil
+}
+
+// CPUTime 读取cgroup里所有进程已经使用的CPU时间 (ms)
+func (cg *Cgroup) CPUTime() (int, error) {
+	cpuStat, err := cg.readKeyValues("cpu.stat")
+	if err != nil {
+		return 0, err
+	}
+	return int(cpuStat["usage_usec"] / 1000), nil
+}
+
+// Tasks 读取cgroup里当前的进程/线程数
+func (cg *Cgroup) Tasks() (int, error) {
+	current, err := ioutil.ReadFile(path.Join(cg.Path, "pids.current"))
+	if err != nil {
+		return 0, errors.Errorf("read pids.current error: %s", err.Error())
+	}
+	return strconv.Atoi(strings.TrimSpace(string(current)))
+}
+
+// Kill 杀死cgroup里所有的进程，返回被杀死的进程数
+func (cg *Cgroup) Kill() (int, error) {
+	pids, err := cg.Pids()
+	if err != nil {
+		return 0, err
+	}
+	if len(pids) == 0 {
+		return 0, nil
+	}
+	// cgroup.kill 在 5.14 以后的内核才有
+	if err := cg.write("cgroup.kill", "1"); err != nil {
+		for _, pid := range pids {
+			_ = syscall.Kill(pid, syscall.SIGKILL)
+		}
+	}
+	return len(pids), nil
+}
+
+// Pids 获取cgroup里所有进程的pid
+func (cg *Cgroup) Pids() ([]int, error) {
+	content, err := ioutil.ReadFile(path.Join(cg.Path, "cgroup.procs"))
+	if err != nil {
+		return nil, errors.Errorf("read cgroup.procs error: %s", err.Error())
+	}
+	pids := make([]int, 0)
+	for _, line := range strings.Fields(string(content)) {
+		pid, err := strconv.Atoi(line)
+		if err == nil {
+			pids = append(pids, pid)
+		}
+	}
+	return pids, nil
+}
+
+// Destroy 杀死残留的进程并删除cgroup
+func (cg *Cgroup) Destroy() error {
+	if cg.procsFile != nil {
+		_ = cg.procsFile.Close()
+		cg.procsFile = nil
+	}
+	_, _ = cg.Kill()
+	var err error
+	// 被杀死的进程退出需要一点时间，在此期间rmdir会返回EBUSY
+	for i := 0; i < 50; i++ {
+		err = syscall.Rmdir(cg.Path)
+		if err == nil || err == syscall.ENOENT {
+			return nil
+		}
+		if err != syscall.EBUSY {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return errors.Errorf("remove cgroup (%s) error: %s", cg.Path, err.Error())
+}
+
+func (cg *Cgroup) write(file, value string) error {
+	err := ioutil.WriteFile(path.Join(cg.Path, file), []byte(value), 0644)
+	if err != nil {
+		return errors.Errorf("write cgroup file (%s) error: %s", file, err.Error())
+	}
+	return nil
+}
+
+// 读取形如 "key value" 的统计文件
+func (cg *Cgroup) readKeyValues(file string) (map[string]int64, error) {
+	fp, err := os.Open(path.Join(cg.Path, file))
+	if err != nil {
+		return nil, errors.Errorf("read cgroup file (%s) error: %s", file, err.Error())
+	}
+	defer fp.Close()
+	values := map[string]int64{}
+	scanner := bufio.NewScanner(fp)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		value, err := strconv.ParseInt(fields[1], 10, 64)
+		if err == nil {
+			values[fields[0]] = value
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,49 @@
+//go:build !linux
+// +build !linux
+
+package cgroup
+
+import "github.com/LanceLRQ/deer-executor/v2/common/sandbox/forkexec"
+
+// Cgroup 一个评测进程专用的临时cgroup
+type Cgroup struct {
+	Path string // cgroup目录
+}
+
+// New 在root下创建一个名为name的cgroup并设置资源限制
+func New(root, name string, limits Limits) (*Cgroup, error) {
+	return nil, ErrNotSupported
+}
+
+// Apply 让即将启动的子进程在exec前加入这个cgroup
+func (cg *Cgroup) Apply(sys *forkexec.SysProcAttr) {}
+
+// Stat 读取cgroup的资源统计信息
+func (cg *Cgroup) Stat() (*Stat, error) {
+	return nil, ErrNotSupported
+}
+
+// CPUTime 读取cgroup里所有进程已经使用的CPU时间 (ms)
+func (cg *Cgroup) CPUTime() (int, error) {
+	return 0, ErrNotSupported
+}
+
+// Tasks 读取cgroup里当前的进程/线程数
+func (cg *Cgroup) Tasks() (int, error) {
+	return 0, ErrNotSupported
+}
+
+// Kill 杀死cgroup里所有的进程，返回被杀死的进程数
+func (cg *Cgroup) Kill() (int, error) {
+	return 0, ErrNotSupported
+}
+
+// Pids 获取cgroup里所有进程的pid
+func (cg *Cgroup) Pids() ([]int, error) {
+	return nil, ErrNotSupported
+}
+
+// Destroy 杀死残留的进程并删除cgroup
+func (cg *Cgroup) Destroy() error {
+	return nil
+}
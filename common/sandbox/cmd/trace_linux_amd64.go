@@ -0,0 +1,94 @@
+//go:build linux && amd64
+// +build linux,amd64
+
+package cmd
+
+import (
+	"os"
+	"syscall"
+)
+
+const (
+	_PTRACE_O_TRACESECCOMP = 0x80
+	_PTRACE_O_EXITKILL     = 0x100000
+	_PTRACE_EVENT_SECCOMP  = 7
+)
+
+// WaitSeccomp 等待一个启用了seccomp过滤的进程退出
+// 进程必须以Ptrace和Setpgid(Pgid=0)启动，并且调用者在StartProcess之前调用了runtime.LockOSThread。
+// 进程的线程也会被跟踪，任意线程调用了被拦截的系统调用时，整个进程会被杀死，
+// 此时返回该系统调用的调用号，否则返回-1。
+// 只允许启动进程时的那一次execve：之后再次exec成功时（新程序还没有开始运行）也会杀死整个进程。
+func (p *Process) WaitSeccomp() (ps *ProcessState, restricted int, err error) {
+	if p.Pid == -1 {
+		return nil, -1, syscall.EINVAL
+	}
+	restricted = -1
+	traced := false
+	var (
+		status syscall.WaitStatus
+		rusage syscall.Rusage
+		regs   syscall.PtraceRegs
+	)
+	for {
+		// 进程组里的线程都是被跟踪的子任务，-Pid等待整个进程组
+		wpid, e := syscall.Wait4(-p.Pid, &status, syscall.WALL, &rusage)
+		if e == syscall.EINTR {
+			continue
+		}
+		if e != nil {
+			return nil, restricted, os.NewSyscallError("wait", e)
+		}
+		if status.Exited() || status.Signaled() {
+			if wpid != p.Pid {
+				// 其他线程退出
+				continue
+			}
+			p.setDone()
+			ps = &ProcessState{
+				pid:    wpid,
+				status: status,
+				rusage: &rusage,
+			}
+			return ps, restricted, nil
+		}
+		if !status.Stopped() {
+			continue
+		}
+		sig := status.StopSignal()
+		switch {
+		case sig == syscall.SIGTRAP && status.TrapCause() == _PTRACE_EVENT_SECCOMP:
+			// 命中了过滤规则，记录调用号后杀死整个进程
+			if restricted == -1 {
+				if e = syscall.PtraceGetRegs(wpid, &regs); e == nil {
+					restricted = int(regs.Orig_rax)
+				}
+			}
+			_ = syscall.Kill(p.Pid, syscall.SIGKILL)
+			continue
+		case sig == syscall.SIGTRAP && status.TrapCause() == syscall.PTRACE_EVENT_EXEC:
+			// 过滤规则按参数的地址放行启动时的execve，这个地址是可以伪造的，第二次exec一律视为被拦截
+			if restricted == -1 {
+				restricted = syscall.SYS_EXECVE
+				if e = syscall.PtraceGetRegs(wpid, &regs); e == nil {
+					restricted = int(regs.Orig_rax)
+				}
+			}
+			_ = syscall.Kill(p.Pid, syscall.SIGKILL)
+			continue
+		case sig == syscall.SIGTRAP && status.TrapCause() == syscall.PTRACE_EVENT_CLONE:
+			sig = 0
+		case sig == syscall.SIGTRAP && !traced && wpid == p.Pid:
+			// execve成功后的第一次停止，设置跟踪选项
+			traced = true
+			if e = syscall.PtraceSetOptions(wpid, syscall.PTRACE_O_TRACECLONE|syscall.PTRACE_O_TRACEEXEC|_PTRACE_O_TRACESECCOMP|_PTRACE_O_EXITKILL); e != nil {
+				_ = syscall.Kill(p.Pid, syscall.SIGKILL)
+			}
+			sig = 0
+		case sig == syscall.SIGSTOP:
+			// 新线程被自动跟踪时会先收到一个SIGSTOP
+			sig = 0
+		}
+		_ = syscall.PtraceCont(wpid, int(sig))
+	}
+}
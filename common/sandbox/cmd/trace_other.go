@@ -0,0 +1,11 @@
+//go:build !linux || !amd64
+// +build !linux !amd64
+
+package cmd
+
+import "errors"
+
+// WaitSeccomp 等待一个启用了seccomp过滤的进程退出（当前平台不支持）
+func (p *Process) WaitSeccomp() (ps *ProcessState, restricted int, err error) {
+	return nil, -1, errors.New("seccomp is not supported on this platform")
+}
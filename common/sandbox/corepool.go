@@ -0,0 +1,50 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package sandbox
+
+import (
+	"context"
+	"github.com/pkg/errors"
+)
+
+// CorePool 独占CPU核心池
+// 每个评测会话租用一个核心，目标程序和判题程序都绑定在这个核心上运行，避免和其他会话或评测机自己争抢CPU导致计时不稳定
+type CorePool struct {
+	cores chan int
+}
+
+// NewCorePool 用给定的核心列表创建核心池
+func NewCorePool(cores []int) (*CorePool, error) {
+	if len(cores) == 0 {
+		return nil, errors.Errorf("empty cpu core list")
+	}
+	pool := &CorePool{cores: make(chan int, len(cores))}
+	seen := map[int]bool{}
+	for _, core := range cores {
+		if core < 0 {
+			return nil, errors.Errorf("invalid cpu core: %d", core)
+		}
+		if seen[core] {
+			return nil, errors.Errorf("duplicate cpu core: %d", core)
+		}
+		seen[core] = true
+		pool.cores <- core
+	}
+	return pool, nil
+}
+
+// Acquire 租用一个核心，没有空闲的核心时阻塞到有核心被归还为止；ctx被取消时返回ctx.Err()
+func (pool *CorePool) Acquire(ctx context.Context) (int, error) {
+	select {
+	case core := <-pool.cores:
+		return core, nil
+	case <-ctx.Done():
+		return -1, ctx.Err()
+	}
+}
+
+// Release 归还核心
+func (pool *CorePool) Release(core int) {
+	pool.cores <- core
+}
@@ -12,6 +12,9 @@ import (
 	"unsafe"
 )
 
+// syscall包里没有定义RLIMIT_NPROC
+const rlimitNproc = 0x7
+
 // Find the entry point for f. See comments in runtime/proc.go for the
 // function of the same name.
 //
@@ -4,6 +4,7 @@
 package forkexec
 
 import (
+	"github.com/LanceLRQ/deer-executor/v2/common/sandbox/seccomp"
 	"runtime"
 	"syscall"
 	"unsafe"
@@ -62,13 +63,40 @@ type SysProcAttr struct {
 	GidMappingsEnableSetgroups bool
 	AmbientCaps                []uintptr  // Ambient capabilities (Linux only)
 	Rlimit                     ExecRLimit // Set child's rlimit.
+	// UseCgroupFD tells the child to join the cgroup whose cgroup.procs
+	// file is opened as CgroupFD before exec (Linux only).
+	UseCgroupFD bool
+	CgroupFD    int
+	// RootFS builds a minimal root filesystem and pivots into it before
+	// Chroot. CLONE_NEWNS must be set in Cloneflags (Linux only).
+	RootFS *RootFS
+	// Seccomp installs a seccomp-bpf filter built from the profile right
+	// before exec. Blocked syscalls stop the child with PTRACE_EVENT_SECCOMP,
+	// so Ptrace must be set as well (Linux only).
+	Seccomp *seccomp.Profile
+	// CPUAffinity pins the child to these CPUs with sched_setaffinity
+	// before exec (Linux only).
+	CPUAffinity []int
 }
 
+// cpuSet is the kernel cpu_set_t, large enough for 1024 CPUs.
+type cpuSet [16]uint64
+
 const _LINUX_CAPABILITY_VERSION_3 = 0x20080522
 
+// syscall包里没有定义RLIMIT_NPROC
+const rlimitNproc = 0x6
+
+const (
+	_PR_SET_NO_NEW_PRIVS = 38
+	_SECCOMP_MODE_FILTER = 2
+)
+
 var (
 	none  = [...]byte{'n', 'o', 'n', 'e', 0}
 	slash = [...]byte{'/', 0}
+	// writing pid 0 into cgroup.procs moves the writer itself.
+	selfPid = [...]byte{'0'}
 )
 
 // fcntl64Syscall is usually SYS_FCNTL, but is overridden on 32-bit Linux
@@ -137,6 +165,44 @@ func forkAndExecInChild1(argv0 *byte, argv, envv []*byte, chroot, dir *byte, att
 	// Load rlimit options
 	rlimitOptions := GetRlimitOptions(&sys.Rlimit)
 
+	// Prepare root filesystem steps
+	var rootfsSteps []rootfsStep
+	if sys.RootFS != nil {
+		steps, err := sys.RootFS.prepare()
+		if err != nil {
+			err1 = syscall.EINVAL
+			return
+		}
+		rootfsSteps = steps
+	}
+
+	// Build seccomp filter
+	var seccompProg *syscall.SockFprog
+	if sys.Seccomp != nil {
+		filter, err := sys.Seccomp.Build(uintptr(unsafe.Pointer(argv0)))
+		if err != nil {
+			err1 = syscall.EINVAL
+			return
+		}
+		seccompProg = &syscall.SockFprog{
+			Len:    uint16(len(filter)),
+			Filter: &filter[0],
+		}
+	}
+
+	// Build CPU affinity mask
+	var cpuMask *cpuSet
+	if len(sys.CPUAffinity) > 0 {
+		cpuMask = &cpuSet{}
+		for _, cpu := range sys.CPUAffinity {
+			if cpu < 0 || cpu >= len(cpuMask)*64 {
+				err1 = syscall.EINVAL
+				return
+			}
+			cpuMask[cpu/64] |= 1 << (uint(cpu) % 64)
+		}
+	}
+
 	if sys.UidMappings != nil {
 		puid = []byte("/proc/self/uid_map\000")
 		uidmap = formatIDMappings(sys.UidMappings)
@@ -249,6 +315,14 @@ func forkAndExecInChild1(argv0 *byte, argv, envv []*byte, chroot, dir *byte, att
 		}
 	}
 
+	// Join cgroup
+	if sys.UseCgroupFD {
+		_, _, err1 = syscall.RawSyscall(syscall.SYS_WRITE, uintptr(sys.CgroupFD), uintptr(unsafe.Pointer(&selfPid[0])), uintptr(len(selfPid)))
+		if err1 != 0 {
+			goto childerror
+		}
+	}
+
 	// Session ID
 	if sys.Setsid {
 		_, _, err1 = syscall.RawSyscall(syscall.SYS_SETSID, 0, 0, 0)
@@ -342,6 +416,14 @@ func forkAndExecInChild1(argv0 *byte, argv, envv []*byte, chroot, dir *byte, att
 		}
 	}
 
+	// Pivot root
+	for i = 0; i < len(rootfsSteps); i++ {
+		err1 = rootfsSteps[i].run()
+		if err1 != 0 {
+			goto childerror
+		}
+	}
+
 	// Chroot
 	if chroot != nil {
 		_, _, err1 = syscall.RawSyscall(syscall.SYS_CHROOT, uintptr(unsafe.Pointer(chroot)), 0, 0)
@@ -522,6 +604,14 @@ func forkAndExecInChild1(argv0 *byte, argv, envv []*byte, chroot, dir *byte, att
 		}
 	}
 
+	// Pin to the CPUs
+	if cpuMask != nil {
+		_, _, err1 = syscall.RawSyscall(syscall.SYS_SCHED_SETAFFINITY, 0, unsafe.Sizeof(*cpuMask), uintptr(unsafe.Pointer(cpuMask)))
+		if err1 != 0 {
+			goto childerror
+		}
+	}
+
 	// Set resource limitations
 	for _, rlimit := range rlimitOptions.Rlimits {
 		if !rlimit.Enable {
@@ -541,6 +631,18 @@ func forkAndExecInChild1(argv0 *byte, argv, envv []*byte, chroot, dir *byte, att
 		}
 	}
 
+	// Install seccomp filter, the next syscall after it should be execve
+	if seccompProg != nil {
+		_, _, err1 = syscall.RawSyscall6(syscall.SYS_PRCTL, _PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0, 0)
+		if err1 != 0 {
+			goto childerror
+		}
+		_, _, err1 = syscall.RawSyscall(syscall.SYS_PRCTL, syscall.PR_SET_SECCOMP, _SECCOMP_MODE_FILTER, uintptr(unsafe.Pointer(seccompProg)))
+		if err1 != 0 {
+			goto childerror
+		}
+	}
+
 	// Time to exec.
 	_, _, err1 = syscall.RawSyscall(syscall.SYS_EXECVE,
 		uintptr(unsafe.Pointer(argv0)),
@@ -0,0 +1,206 @@
+//go:build linux && amd64
+// +build linux,amd64
+
+package forkexec
+
+import (
+	"os"
+	"path"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// statfs(2)返回的挂载标志
+const (
+	stNoSuid     = 0x2
+	stNoDev      = 0x4
+	stNoExec     = 0x8
+	stNoAtime    = 0x400
+	stNoDirAtime = 0x800
+	stRelAtime   = 0x1000
+)
+
+// BindMount 把宿主机上的一个文件或目录挂载到新的根文件系统里
+type BindMount struct {
+	Source   string // 宿主机上的路径
+	Target   string // 新根文件系统里的路径，为空时与Source相同
+	Writable bool   // 是否可写，默认只读
+}
+
+// RootFS 由只读挂载的宿主机目录组成的最小根文件系统
+// 子进程在新的挂载命名空间里，往Root上挂一个tmpfs，再把Mounts逐个bind mount进去，
+// 最后pivot_root切换过去并把根目录重新挂载为只读。
+// 用户命名空间里不映射宿主机的root，子进程先把文件系统用户切换到UID/GID（tmpfs里只能以有映射的用户创建文件），
+// 此时它仍然拥有命名空间里的所有权限，可以完成挂载；exec之后这些权限随之失去。
+type RootFS struct {
+	Root   string      // 宿主机上的一个空目录，用作新根目录的挂载点（只在子进程的挂载命名空间里生效，可以被多个进程共用）
+	Mounts []BindMount // 挂载列表，不存在的Source会被忽略
+	Proc   bool        // 是否挂载/proc（需要同时创建新的PID命名空间）
+	UID    int         // 构建根文件系统时使用的文件系统用户（必须在用户命名空间里有映射）
+	GID    int         // 构建根文件系统时使用的文件系统用户组（必须在用户命名空间里有映射）
+}
+
+// 子进程里要执行的一步操作，所有的参数都在fork之前准备好
+type rootfsStep struct {
+	trap   uintptr
+	path1  *byte
+	path2  *byte
+	fstype *byte
+	data   *byte
+	flags  uintptr
+	ignore syscall.Errno // 可以忽略的错误（如mkdir时的EEXIST）
+}
+
+var (
+	tmpfs     = [...]byte{'t', 'm', 'p', 'f', 's', 0}
+	procfs    = [...]byte{'p', 'r', 'o', 'c', 0}
+	dot       = [...]byte{'.', 0}
+	tmpfsData = [...]byte{'m', 'o', 'd', 'e', '=', '0', '7', '5', '5', ',', 's', 'i', 'z', 'e', '=', '1', '6', 'm', 0}
+)
+
+// 生成构建根文件系统的操作序列
+func (r *RootFS) prepare() ([]rootfsStep, error) {
+	root, err := syscall.BytePtrFromString(r.Root)
+	if err != nil {
+		return nil, err
+	}
+	steps := []rootfsStep{
+		{trap: syscall.SYS_SETFSGID, flags: uintptr(r.GID)},
+		{trap: syscall.SYS_SETFSUID, flags: uintptr(r.UID)},
+		// 不要把挂载事件传播回宿主机
+		{trap: syscall.SYS_MOUNT, path1: &none[0], path2: &slash[0], flags: syscall.MS_REC | syscall.MS_PRIVATE},
+		{trap: syscall.SYS_MOUNT, path1: &tmpfs[0], path2: root, fstype: &tmpfs[0], data: &tmpfsData[0], flags: syscall.MS_NOSUID | syscall.MS_NODEV},
+	}
+	created := map[string]bool{}
+	mkdirAll := func(dir string) error {
+		parts := strings.Split(strings.Trim(dir, "/"), "/")
+		current := "/"
+		for _, part := range parts {
+			if part == "" {
+				continue
+			}
+			current = path.Join(current, part)
+			if created[current] {
+				continue
+			}
+			created[current] = true
+			p, err := syscall.BytePtrFromString(path.Join(r.Root, current))
+			if err != nil {
+				return err
+			}
+			steps = append(steps, rootfsStep{trap: syscall.SYS_MKDIR, path1: p, flags: 0755, ignore: syscall.EEXIST})
+		}
+		return nil
+	}
+	for _, m := range r.Mounts {
+		info, err := os.Stat(m.Source)
+		if err != nil {
+			continue
+		}
+		var stat syscall.Statfs_t
+		if err = syscall.Statfs(m.Source, &stat); err != nil {
+			return nil, err
+		}
+		target := m.Target
+		if target == "" {
+			target = m.Source
+		}
+		source, err := syscall.BytePtrFromString(m.Source)
+		if err != nil {
+			return nil, err
+		}
+		dest, err := syscall.BytePtrFromString(path.Join(r.Root, target))
+		if err != nil {
+			return nil, err
+		}
+		// bind mount的目标必须和源的类型一致
+		if info.IsDir() {
+			err = mkdirAll(target)
+		} else {
+			err = mkdirAll(path.Dir(target))
+			steps = append(steps, rootfsStep{trap: syscall.SYS_MKNODAT, path1: dest, flags: syscall.S_IFREG | 0644, ignore: syscall.EEXIST})
+		}
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, rootfsStep{trap: syscall.SYS_MOUNT, path1: source, path2: dest, flags: syscall.MS_BIND | syscall.MS_REC})
+		// 重新挂载时必须保留源挂载点上被锁定的标志，否则在用户命名空间里会返回EPERM
+		flags := uintptr(syscall.MS_BIND | syscall.MS_REMOUNT | syscall.MS_NOSUID | mountFlags(stat.Flags))
+		if !m.Writable {
+			flags |= syscall.MS_RDONLY
+		}
+		steps = append(steps, rootfsStep{trap: syscall.SYS_MOUNT, path1: &none[0], path2: dest, flags: flags})
+	}
+	if r.Proc {
+		if err = mkdirAll("/proc"); err != nil {
+			return nil, err
+		}
+		dest, err := syscall.BytePtrFromString(path.Join(r.Root, "proc"))
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, rootfsStep{trap: syscall.SYS_MOUNT, path1: &procfs[0], path2: dest, fstype: &procfs[0], flags: syscall.MS_NOSUID | syscall.MS_NODEV | syscall.MS_NOEXEC})
+	}
+	// pivot_root(".", ".")之后旧的根目录叠在新根目录的下面，直接卸载掉即可
+	steps = append(steps,
+		rootfsStep{trap: syscall.SYS_CHDIR, path1: root},
+		rootfsStep{trap: syscall.SYS_PIVOT_ROOT, path1: &dot[0], path2: &dot[0]},
+		rootfsStep{trap: syscall.SYS_UMOUNT2, path1: &dot[0], flags: syscall.MNT_DETACH},
+		rootfsStep{trap: syscall.SYS_CHDIR, path1: &slash[0]},
+		rootfsStep{trap: syscall.SYS_MOUNT, path1: &none[0], path2: &slash[0], flags: syscall.MS_BIND | syscall.MS_REMOUNT | syscall.MS_RDONLY | syscall.MS_NOSUID | syscall.MS_NODEV},
+	)
+	return steps, nil
+}
+
+// 把statfs返回的标志转换成mount的标志
+func mountFlags(flags int64) int {
+	result := 0
+	if flags&stNoSuid != 0 {
+		result |= syscall.MS_NOSUID
+	}
+	if flags&stNoDev != 0 {
+		result |= syscall.MS_NODEV
+	}
+	if flags&stNoExec != 0 {
+		result |= syscall.MS_NOEXEC
+	}
+	if flags&stNoAtime != 0 {
+		result |= syscall.MS_NOATIME
+	}
+	if flags&stNoDirAtime != 0 {
+		result |= syscall.MS_NODIRATIME
+	}
+	if flags&stRelAtime != 0 {
+		result |= syscall.MS_RELATIME
+	}
+	return result
+}
+
+// 在子进程里执行一步操作
+// 和forkAndExecInChild1一样，这里不能分配内存
+//
+//go:nosplit
+//go:norace
+func (s *rootfsStep) run() syscall.Errno {
+	var err1 syscall.Errno
+	dirfd := int(_AT_FDCWD)
+	switch s.trap {
+	case syscall.SYS_MOUNT:
+		_, _, err1 = syscall.RawSyscall6(s.trap, uintptr(unsafe.Pointer(s.path1)), uintptr(unsafe.Pointer(s.path2)), uintptr(unsafe.Pointer(s.fstype)), s.flags, uintptr(unsafe.Pointer(s.data)), 0)
+	case syscall.SYS_MKNODAT:
+		_, _, err1 = syscall.RawSyscall6(s.trap, uintptr(dirfd), uintptr(unsafe.Pointer(s.path1)), s.flags, 0, 0, 0)
+	case syscall.SYS_PIVOT_ROOT:
+		_, _, err1 = syscall.RawSyscall(s.trap, uintptr(unsafe.Pointer(s.path1)), uintptr(unsafe.Pointer(s.path2)), 0)
+	case syscall.SYS_SETFSUID, syscall.SYS_SETFSGID:
+		// 返回值是之前的id，不会出错
+		syscall.RawSyscall(s.trap, s.flags, 0, 0)
+	default:
+		// mkdir(path, mode) / chdir(path) / umount2(path, flags)
+		_, _, err1 = syscall.RawSyscall(s.trap, uintptr(unsafe.Pointer(s.path1)), s.flags, 0)
+	}
+	if err1 == s.ignore {
+		return 0
+	}
+	return err1
+}
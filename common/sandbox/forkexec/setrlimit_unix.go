@@ -40,11 +40,14 @@ type TimeVal struct {
 
 // ExecRLimit  Exec rlimit options
 type ExecRLimit struct {
-	TimeLimit     int // 时间限制 (ms)
-	RealTimeLimit int // 真实时间限制 (ms, 触发SIGALRM)
-	MemoryLimit   int // 内存限制 (KB)
-	FileSizeLimit int // 文件读写限制 (B)
-	StackLimit    int // 栈大小限制 (KB，0表示用内存限制的值，-1表示不限制，建议设置为2倍。Mac下有坑，不要去设置。)
+	TimeLimit      int  // 时间限制 (ms)
+	RealTimeLimit  int  // 真实时间限制 (ms, 触发SIGALRM)
+	MemoryLimit    int  // 内存限制 (KB)
+	FileSizeLimit  int  // 文件读写限制 (B)
+	StackLimit     int  // 栈大小限制 (KB，0表示用内存限制的值，-1表示不限制，建议设置为2倍。Mac下有坑，不要去设置。)
+	ProcessLimit   int  // 进程/线程数限制 (RLIMIT_NPROC，Linux下线程也计入。按真实用户统计，只应在以单独的用户运行时设置)
+	OpenFilesLimit int  // 打开的文件数限制 (RLIMIT_NOFILE，0表示不限制)
+	CoreDump       bool // 是否允许生成core文件 (为false时把RLIMIT_CORE设置为0)
 }
 
 // RlimitOptions rlimit options
@@ -108,6 +111,33 @@ func GetRlimitOptions(sysRlimit *ExecRLimit) *RlimitOptions {
 					Max: uint64(sysRlimit.FileSizeLimit),
 				},
 			},
+			// Set open files limit: RLIMIT_NOFILE
+			{
+				Which:  syscall.RLIMIT_NOFILE,
+				Enable: sysRlimit.OpenFilesLimit > 0,
+				RLim: syscall.Rlimit{
+					Cur: uint64(sysRlimit.OpenFilesLimit),
+					Max: uint64(sysRlimit.OpenFilesLimit),
+				},
+			},
+			// Disable core dump: RLIMIT_CORE
+			{
+				Which:  syscall.RLIMIT_CORE,
+				Enable: !sysRlimit.CoreDump,
+				RLim: syscall.Rlimit{
+					Cur: 0,
+					Max: 0,
+				},
+			},
+			// Set process limit: RLIMIT_NPROC
+			{
+				Which:  rlimitNproc,
+				Enable: sysRlimit.ProcessLimit > 0,
+				RLim: syscall.Rlimit{
+					Cur: uint64(sysRlimit.ProcessLimit),
+					Max: uint64(sysRlimit.ProcessLimit),
+				},
+			},
 		},
 		ITimerValue: ITimerVal{
 			ItInterval: TimeVal{
@@ -0,0 +1,101 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package sandbox
+
+import (
+	"github.com/LanceLRQ/deer-executor/v2/common/constants"
+	"github.com/pkg/errors"
+	"syscall"
+	"time"
+)
+
+// MemoryStrategy 内存峰值的统计方式
+type MemoryStrategy string
+
+// 内存峰值的统计方式，不同的方式得到的数值可能相差很大，对比不同评测机的结果时需要看清楚使用的是哪一种
+const (
+	MemoryStrategyAuto   MemoryStrategy = ""            // 启用cgroup时使用cgroup_peak，否则使用minflt
+	MemoryStrategyMinflt MemoryStrategy = "minflt"      // 缺页次数×页大小，释放后重新申请的内存会被重复计入
+	MemoryStrategyMaxRSS MemoryStrategy = "maxrss"      // rusage里的常驻内存峰值
+	MemoryStrategyVmHWM  MemoryStrategy = "vmhwm"       // 轮询/proc/[pid]/status里的VmHWM（仅Linux），最后一次采样之后的增长不会被计入
+	MemoryStrategyCgroup MemoryStrategy = "cgroup_peak" // cgroup的memory.peak，包含所有子进程（需要启用cgroup）
+)
+
+// ParseMemoryStrategy 检查内存峰值统计方式的名称
+func ParseMemoryStrategy(name string) (MemoryStrategy, error) {
+	switch s := MemoryStrategy(name); s {
+	case MemoryStrategyAuto, MemoryStrategyMinflt, MemoryStrategyMaxRSS, MemoryStrategyVmHWM, MemoryStrategyCgroup:
+		return s, nil
+	}
+	return "", errors.Errorf("unknown memory strategy: %s", name)
+}
+
+// VmHWM采样
+type memorySampler struct {
+	quit chan struct{}
+	done chan struct{}
+	peak int // 采样到的VmHWM最大值 (KB)
+}
+
+// 按固定的间隔读取进程的VmHWM。进程退出后/proc下的信息就没有了，所以只能在运行过程中采样
+func sampleMemory(strategy MemoryStrategy, pid int) *memorySampler {
+	if strategy != MemoryStrategyVmHWM {
+		return nil
+	}
+	s := &memorySampler{
+		quit: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(constants.MemorySamplerInterval * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			_, hwm, err := readProcessMemory(pid)
+			if err != nil {
+				// 进程已经退出
+				return
+			}
+			if hwm > s.peak {
+				s.peak = hwm
+			}
+			select {
+			case <-s.quit:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return s
+}
+
+// 停止采样，返回采样到的峰值 (KB)
+func (s *memorySampler) stop() int {
+	if s == nil {
+		return 0
+	}
+	close(s.quit)
+	<-s.done
+	return s.peak
+}
+
+// 按照统计方式计算内存峰值 (KB)，返回实际使用的统计方式。
+// 要求的数据不可用时（没有启用cgroup、进程在第一次采样之前就退出了）退回到其他方式
+func (u *Usage) measureMemory(strategy MemoryStrategy, sampled int) (int, MemoryStrategy) {
+	ru := u.Rusage
+	switch strategy {
+	case MemoryStrategyMaxRSS:
+		return maxRSS(ru), MemoryStrategyMaxRSS
+	case MemoryStrategyVmHWM:
+		if sampled > 0 {
+			return sampled, MemoryStrategyVmHWM
+		}
+		return maxRSS(ru), MemoryStrategyMaxRSS
+	case MemoryStrategyAuto, MemoryStrategyCgroup:
+		if u.CgroupStat != nil && u.CgroupStat.MemoryPeak > 0 {
+			return u.CgroupStat.MemoryPeak, MemoryStrategyCgroup
+		}
+	}
+	return int(ru.Minflt * int64(syscall.Getpagesize()/1024)), MemoryStrategyMinflt
+}
@@ -0,0 +1,67 @@
+//go:build darwin
+// +build darwin
+
+package sandbox
+
+import (
+	"github.com/pkg/errors"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// 读取进程已经使用的CPU时间 (ms)（darwin上没有/proc，只依靠RLIMIT_CPU）
+func readProcessCPUTime(pid int) (int, error) {
+	return 0, errors.Errorf("cpu time supervisor is not supported on darwin")
+}
+
+// 读取进程当前的常驻内存和峰值（darwin上没有/proc，使用maxrss）
+func readProcessMemory(pid int) (int, int, error) {
+	return 0, 0, errors.Errorf("memory sampling is not supported on darwin")
+}
+
+// rusage里的常驻内存峰值 (KB)，darwin上ru_maxrss的单位是字节
+func maxRSS(ru *syscall.Rusage) int {
+	return int(ru.Maxrss / 1024)
+}
+
+// 统计进程组里的进程/线程总数（darwin上没有/proc，依靠RLIMIT_NPROC）
+func countProcessGroupTasks(pgid int) (int, error) {
+	return 0, errors.Errorf("task monitor is not supported on darwin")
+}
+
+// 杀死进程组里残留的进程，返回被杀死的进程数
+// 先暂停整个进程组，防止它们在统计的过程中继续fork
+func killProcessGroup(pgid int) int {
+	if pgid <= 0 {
+		return 0
+	}
+	if err := syscall.Kill(-pgid, syscall.SIGSTOP); err != nil {
+		// ESRCH: 进程组里已经没有进程了
+		return 0
+	}
+	count := 0
+	out, err := exec.Command("pgrep", "-g", strconv.Itoa(pgid)).Output()
+	if err == nil {
+		count = len(strings.Fields(string(out)))
+	}
+	_ = syscall.Kill(-pgid, syscall.SIGKILL)
+	return count
+}
+
+// KillUserProcesses 杀死属于uid的所有进程，返回被杀死的进程数
+func KillUserProcesses(uid int) int {
+	if uid <= 0 {
+		return 0
+	}
+	count := 0
+	out, err := exec.Command("pgrep", "-U", strconv.Itoa(uid)).Output()
+	if err == nil {
+		count = len(strings.Fields(string(out)))
+	}
+	if count > 0 {
+		_ = exec.Command("pkill", "-KILL", "-U", strconv.Itoa(uid)).Run()
+	}
+	return count
+}
@@ -0,0 +1,227 @@
+//go:build linux
+// +build linux
+
+package sandbox
+
+import (
+	"bytes"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// USER_HZ, /proc/[pid]/stat里utime和stime的单位（x86和arm上总是100）
+const clockTicks = 100
+
+// 读取进程已经使用的CPU时间 (ms)
+// /proc/[pid]/stat的精度只有10ms，所以优先累加各个线程schedstat里的运行时间(ns)；
+// 已经退出的线程不在task目录里，但会计入stat，两者取较大值
+func readProcessCPUTime(pid int) (int, error) {
+	procDir := path.Join("/proc", strconv.Itoa(pid))
+	stat, err := ioutil.ReadFile(path.Join(procDir, "stat"))
+	if err != nil {
+		return 0, err
+	}
+	used := 0
+	// pid (comm) state ppid ... utime(14) stime(15)，comm里可能有空格和括号
+	if end := bytes.LastIndexByte(stat, ')'); end >= 0 {
+		fields := bytes.Fields(stat[end+1:])
+		if len(fields) > 12 {
+			utime, _ := strconv.Atoi(string(fields[11]))
+			stime, _ := strconv.Atoi(string(fields[12]))
+			used = (utime + stime) * 1000 / clockTicks
+		}
+	}
+	tasks, err := ioutil.ReadDir(path.Join(procDir, "task"))
+	if err != nil {
+		return used, nil
+	}
+	var total int64
+	for _, task := range tasks {
+		schedstat, err := ioutil.ReadFile(path.Join(procDir, "task", task.Name(), "schedstat"))
+		if err != nil {
+			continue
+		}
+		fields := strings.Fields(string(schedstat))
+		if len(fields) == 0 {
+			continue
+		}
+		ns, err := strconv.ParseInt(fields[0], 10, 64)
+		if err == nil {
+			total += ns
+		}
+	}
+	if ms := int(total / 1000000); ms > used {
+		used = ms
+	}
+	return used, nil
+}
+
+// 读取进程当前的常驻内存VmRSS和峰值VmHWM (KB)
+func readProcessMemory(pid int) (int, int, error) {
+	status, err := ioutil.ReadFile(path.Join("/proc", strconv.Itoa(pid), "status"))
+	if err != nil {
+		return 0, 0, err
+	}
+	rss, hwm := -1, -1
+	for _, line := range strings.Split(string(status), "\n") {
+		// VmHWM:	    1234 kB
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "VmRSS:":
+			rss, _ = strconv.Atoi(fields[1])
+		case "VmHWM:":
+			hwm, _ = strconv.Atoi(fields[1])
+		}
+	}
+	if rss < 0 || hwm < 0 {
+		// 僵尸进程没有内存信息
+		return 0, 0, errors.Errorf("no memory info in /proc/%d/status", pid)
+	}
+	return rss, hwm, nil
+}
+
+// rusage里的常驻内存峰值 (KB)，Linux上ru_maxrss的单位是KB
+func maxRSS(ru *syscall.Rusage) int {
+	return int(ru.Maxrss)
+}
+
+// 进程组里的一个进程
+type groupProcess struct {
+	pid     int
+	threads int
+}
+
+// 扫描进程组里所有的进程（不包括僵尸进程）
+func scanProcessGroup(pgid int) []groupProcess {
+	entries, err := ioutil.ReadDir("/proc")
+	if err != nil {
+		return nil
+	}
+	procs := make([]groupProcess, 0)
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		stat, err := ioutil.ReadFile(path.Join("/proc", entry.Name(), "stat"))
+		if err != nil {
+			continue
+		}
+		// /proc/[pid]/stat: pid (comm) state ppid pgrp ... num_threads(20)，comm里可能有空格和括号
+		end := bytes.LastIndexByte(stat, ')')
+		if end < 0 {
+			continue
+		}
+		fields := bytes.Fields(stat[end+1:])
+		if len(fields) < 18 {
+			continue
+		}
+		// 僵尸进程已经退出了，不算作残留进程
+		if string(fields[0]) == "Z" {
+			continue
+		}
+		if pgrp, err := strconv.Atoi(string(fields[2])); err == nil && pgrp == pgid {
+			threads, _ := strconv.Atoi(string(fields[17]))
+			procs = append(procs, groupProcess{pid: pid, threads: threads})
+		}
+	}
+	return procs
+}
+
+// 列出进程组里所有的进程
+func listProcessGroup(pgid int) []int {
+	procs := scanProcessGroup(pgid)
+	pids := make([]int, 0, len(procs))
+	for _, p := range procs {
+		pids = append(pids, p.pid)
+	}
+	return pids
+}
+
+// 统计进程组里的进程/线程总数
+func countProcessGroupTasks(pgid int) (int, error) {
+	count := 0
+	for _, p := range scanProcessGroup(pgid) {
+		count += p.threads
+	}
+	return count, nil
+}
+
+// 杀死进程组里残留的进程，返回被杀死的进程数
+// 先暂停整个进程组，防止它们在统计的过程中继续fork
+func killProcessGroup(pgid int) int {
+	if pgid <= 0 {
+		return 0
+	}
+	if err := syscall.Kill(-pgid, syscall.SIGSTOP); err != nil {
+		// ESRCH: 进程组里已经没有进程了
+		return 0
+	}
+	pids := listProcessGroup(pgid)
+	_ = syscall.Kill(-pgid, syscall.SIGKILL)
+	return len(pids)
+}
+
+// 列出属于uid的所有进程（真实用户或有效用户），不包括僵尸进程
+func listUserProcesses(uid int) []int {
+	entries, err := ioutil.ReadDir("/proc")
+	if err != nil {
+		return nil
+	}
+	pids := make([]int, 0)
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		status, err := ioutil.ReadFile(path.Join("/proc", entry.Name(), "status"))
+		if err != nil {
+			continue
+		}
+		zombie, owned := false, false
+		for _, line := range strings.Split(string(status), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) < 3 {
+				continue
+			}
+			switch fields[0] {
+			case "State:":
+				zombie = fields[1] == "Z"
+			case "Uid:":
+				// Uid: real effective saved filesystem
+				owned = fields[1] == strconv.Itoa(uid) || fields[2] == strconv.Itoa(uid)
+			}
+		}
+		if owned && !zombie {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+// KillUserProcesses 杀死属于uid的所有进程，返回被杀死的进程数
+// 进程可能在扫描的过程中继续fork，所以反复扫描直到没有新的进程为止
+func KillUserProcesses(uid int) int {
+	if uid <= 0 {
+		return 0
+	}
+	killed := map[int]bool{}
+	for round := 0; round < 10; round++ {
+		pids := listUserProcesses(uid)
+		if len(pids) == 0 {
+			break
+		}
+		for _, pid := range pids {
+			_ = syscall.Kill(pid, syscall.SIGKILL)
+			killed[pid] = true
+		}
+	}
+	return len(killed)
+}
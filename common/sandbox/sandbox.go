@@ -0,0 +1,427 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package sandbox
+
+import (
+	"context"
+	"github.com/LanceLRQ/deer-executor/v2/common/constants"
+	"github.com/LanceLRQ/deer-executor/v2/common/sandbox/cgroup"
+	"github.com/LanceLRQ/deer-executor/v2/common/sandbox/cmd"
+	"github.com/LanceLRQ/deer-executor/v2/common/sandbox/forkexec"
+	"github.com/LanceLRQ/deer-executor/v2/common/sandbox/seccomp"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// Limit 进程被终止时触发的资源限制
+type Limit string
+
+// 资源限制的类型
+const (
+	LimitNone     Limit = ""          // 没有触发资源限制
+	LimitTime     Limit = "time"      // CPU时间限制
+	LimitRealTime Limit = "real_time" // 真实时间限制
+	LimitMemory   Limit = "memory"    // 内存限制
+	LimitOutput   Limit = "output"    // 输出文件大小限制
+	LimitIdleness Limit = "idleness"  // 空闲时间限制（真实时间在增长而CPU时间没有变化）
+	LimitSyscall  Limit = "syscall"   // 调用了被禁止的系统调用
+	LimitProcess  Limit = "process"   // 进程/线程数限制
+)
+
+// Mount 挂载到隔离环境里的宿主机路径
+type Mount struct {
+	Source   string // 宿主机上的路径
+	Target   string // 隔离环境里的路径，为空时与Source相同
+	Writable bool   // 是否可写，默认只读
+}
+
+// Isolation 命名空间隔离参数（仅Linux支持）
+// 进程运行在新的user/mount/pid/ipc/uts/net命名空间里，根目录被替换为由Mounts组成的最小文件系统
+type Isolation struct {
+	Mounts []Mount // 挂载列表，不存在的Source会被忽略
+	UID    int     // 运行进程的用户，0表示使用Spec.UID，两者都没有设置时为nobody(65534)
+	GID    int     // 运行进程的用户组，UID为0时跟随Spec.GID，否则0表示nogroup(65534)
+}
+
+// CgroupOptions cgroup v2参数（仅Linux支持），内存限制使用Spec.Limits.MemoryLimit
+type CgroupOptions struct {
+	Root      string // cgroup v2根目录
+	Name      string // cgroup名称，为空时随机生成
+	PidsLimit int    // 进程/线程数限制，0表示不限制
+	CPULimit  int    // CPU带宽限制（百分比），0表示不限制
+}
+
+// Spec 沙箱运行参数
+type Spec struct {
+	Path   string        // 可执行文件的路径，为空时使用Args[0]（不包含路径分隔符时从PATH里查找）
+	Args   []string      // 命令行参数，包括argv[0]
+	Env    []string      // 环境变量
+	Dir    string        // 工作目录
+	Files  []interface{} // 依次为stdin, stdout, stderr...，支持*os.File或者uintptr（管道的文件描述符），由调用方负责关闭
+	Limits forkexec.ExecRLimit
+	UID    int   // 运行进程的用户，0表示不切换（沿用评测机自己的身份）
+	GID    int   // 运行进程的用户组，0表示与UID相同
+	CPUs   []int // 绑定运行的CPU核心（仅Linux），为空时不绑定
+
+	Isolation *Isolation       // 命名空间隔离，nil表示不启用
+	Seccomp   *seccomp.Profile // 系统调用过滤规则，nil表示不启用
+	Cgroup    *CgroupOptions   // cgroup资源控制，nil表示不启用
+
+	SupervisorInterval int            // CPU时间监控的轮询间隔 (ms)，0表示不启用（只依靠RLIMIT_CPU，精度为秒）
+	IdlenessLimit      int            // 空闲时间限制 (ms)，0表示不启用
+	MemoryStrategy     MemoryStrategy // 内存峰值的统计方式，为空时自动选择
+	TimelineInterval   int            // 资源使用时间线的采样间隔 (ms)，0表示不启用
+	TimelineLimit      int            // 时间线最多保留的采样点数，0表示使用默认值
+	ReportPeakTasks    bool           // 统计同时存在的进程/线程数峰值（未启用cgroup时需要轮询/proc）
+}
+
+// Usage 进程的资源使用情况
+type Usage struct {
+	Pid      int `json:"pid"`
+	WallTime int `json:"wall_time"` // 真实时间 (ms)
+	CPUTime  int `json:"cpu_time"`  // CPU时间 (ms)，启用cgroup时来自cpu.stat
+	Memory   int `json:"memory"`    // 内存峰值 (KB)，统计方式见MemoryStrategy
+	ExitCode int `json:"exit_code"` // 退出代码，被信号终止时为-1
+	Signal   int `json:"signal"`    // 终止进程的信号，正常退出时为0
+
+	LimitHit        Limit  `json:"limit_hit"`         // 触发的资源限制
+	TimeLimitKiller string `json:"time_limit_killer"` // 超出时间限制时是谁杀死了进程：supervisor, rlimit或者real_time
+
+	Status     syscall.WaitStatus `json:"status"`
+	Rusage     *syscall.Rusage    `json:"rusage"`
+	CgroupStat *cgroup.Stat       `json:"cgroup_stat"` // cgroup资源统计（未启用cgroup时为nil）
+
+	RestrictedSyscall  string `json:"restricted_syscall"`   // 被seccomp拦截的系统调用名称
+	SupervisorTimeUsed int    `json:"supervisor_time_used"` // CPU时间监控最后一次读取到的CPU时间 (ms)
+	StrayProcesses     int    `json:"stray_processes"`      // 进程退出后被杀死的残留进程数
+	PeakTasks          int    `json:"peak_tasks"`           // 同时存在的进程/线程数峰值（按轮询统计，没有轮询时为0，darwin上为0）
+
+	MemoryStrategy MemoryStrategy `json:"memory_strategy"` // 内存峰值实际使用的统计方式
+	Timeline       []UsageSample  `json:"timeline"`        // 资源使用时间线（未启用或darwin上为nil）
+}
+
+// 运行过程中由评测机自己做出的判断
+type runFlags struct {
+	realTimeExceeded bool // 真实时间看门狗杀死了进程
+	supervisorKilled bool // CPU时间监控杀死了进程
+	idle             bool // 空闲检测杀死了进程
+	tasksExceeded    bool // 进程/线程数监控杀死了进程
+}
+
+// Run 在沙箱里运行一个命令并等待它退出
+// ctx被取消或超时后会杀死整个进程组，并返回ctx.Err()
+func Run(ctx context.Context, spec Spec) (*Usage, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	name, err := lookPath(&spec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := ParseMemoryStrategy(string(spec.MemoryStrategy)); err != nil {
+		return nil, err
+	}
+	sys := &forkexec.SysProcAttr{
+		Rlimit: spec.Limits,
+		// 单独一个进程组，结束时连同它fork出来的进程一起杀死
+		Setpgid: true,
+		Pgid:    0,
+	}
+	isolated, err := applyIsolation(&spec, sys)
+	if err != nil {
+		return nil, err
+	}
+	if !isolated {
+		applyCredential(&spec, sys)
+		if uid, _ := spec.credential(); uid <= 0 {
+			// RLIMIT_NPROC按真实用户统计，以评测机自己的身份运行时会把评测机的进程也算进去，改为只由监控限制
+			sys.Rlimit.ProcessLimit = 0
+		}
+	}
+	if err := applyAffinity(&spec, sys); err != nil {
+		return nil, err
+	}
+	traced, err := applySeccomp(&spec, sys)
+	if err != nil {
+		return nil, err
+	}
+	var cg *cgroup.Cgroup
+	if spec.Cgroup != nil {
+		cg, err = createCgroup(&spec)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = cg.Destroy() }()
+		cg.Apply(sys)
+		// 内存交由cgroup的memory.max限制，不再设置RLIMIT_AS和RLIMIT_DATA
+		if sys.Rlimit.StackLimit == 0 {
+			sys.Rlimit.StackLimit = sys.Rlimit.MemoryLimit * 2
+		}
+		sys.Rlimit.MemoryLimit = 0
+	}
+	if traced {
+		// ptrace的请求必须由启动子进程的线程发起
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+	}
+
+	startTime := time.Now()
+	proc, err := cmd.StartProcess(name, spec.Args, &cmd.ProcAttr{
+		Dir:   spec.Dir,
+		Env:   spec.Env,
+		Files: spec.Files,
+		Sys:   sys,
+	})
+	if err != nil {
+		return nil, err
+	}
+	pid := proc.Pid
+	usage := &Usage{Pid: pid}
+
+	// 取消或超时的时候杀死整个进程组
+	exited := make(chan struct{})
+	cancelled := make(chan bool, 1)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = syscall.Kill(-pid, syscall.SIGKILL)
+			cancelled <- true
+		case <-exited:
+			cancelled <- false
+		}
+	}()
+	var watchdog *realTimeWatchdog
+	if isolated {
+		// 目标程序是PID命名空间里的1号进程，内核不会给它投递SIGALRM
+		watchdog = watchRealTime(spec.Limits.RealTimeLimit, pid)
+	}
+	supervisor := superviseCPUTime(&spec, cg, pid)
+	// 内核能限制进程/线程数时（pids.max，或者以单独的用户运行时的RLIMIT_NPROC），监控只用于统计峰值
+	taskLimit := spec.Limits.ProcessLimit
+	if sys.Rlimit.ProcessLimit > 0 || (cg != nil && spec.Cgroup.PidsLimit > 0) {
+		taskLimit = 0
+	}
+	tasks := monitorTasks(taskLimit, spec.ReportPeakTasks, cg, pid)
+	sampler := sampleMemory(spec.MemoryStrategy, pid)
+	timeline := sampleTimeline(&spec, cg, pid, startTime)
+
+	// Wait for exit.
+	var pstate *cmd.ProcessState
+	restricted := -1
+	if traced {
+		pstate, restricted, err = proc.WaitSeccomp()
+	} else {
+		pstate, err = proc.Wait()
+	}
+	close(exited)
+	killed := <-cancelled
+	usage.WallTime = int(time.Since(startTime) / time.Millisecond)
+	flags := runFlags{realTimeExceeded: watchdog.stop()}
+	supervisor.stop(usage, &flags)
+	tasks.stop(usage, &flags)
+	sampled := sampler.stop()
+	usage.Timeline = timeline.stop()
+	usage.StrayProcesses = killProcessTree(cg, pid)
+	if err != nil {
+		return nil, err
+	}
+	if killed {
+		return nil, ctx.Err()
+	}
+
+	usage.Status = pstate.Sys().(syscall.WaitStatus)
+	usage.Rusage, _ = pstate.SysUsage().(*syscall.Rusage)
+	if usage.Rusage == nil {
+		return nil, errors.Errorf("get rusage failed")
+	}
+	if restricted >= 0 {
+		usage.RestrictedSyscall = seccomp.SyscallName(restricted)
+	}
+	if cg != nil {
+		usage.CgroupStat, err = cg.Stat()
+		if err != nil {
+			return nil, err
+		}
+	}
+	usage.collect(spec.MemoryStrategy, sampled)
+	usage.detectLimit(&spec, &flags)
+	return usage, nil
+}
+
+// 获取运行进程的用户和用户组，uid为0表示不切换
+func (spec *Spec) credential() (int, int) {
+	if spec.UID <= 0 {
+		return 0, 0
+	}
+	if spec.GID <= 0 {
+		return spec.UID, spec.UID
+	}
+	return spec.UID, spec.GID
+}
+
+// 切换到指定的用户运行进程（需要评测机以root运行），同时清空附加用户组
+func applyCredential(spec *Spec, sys *forkexec.SysProcAttr) {
+	uid, gid := spec.credential()
+	if uid <= 0 {
+		return
+	}
+	sys.Credential = &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}
+}
+
+// 获取可执行文件的真实路径（参考exec.Command）
+func lookPath(spec *Spec) (string, error) {
+	name := spec.Path
+	if name == "" {
+		if len(spec.Args) == 0 {
+			return "", errors.Errorf("empty command")
+		}
+		name = spec.Args[0]
+	}
+	if filepath.Base(name) == name {
+		return exec.LookPath(name)
+	}
+	return name, nil
+}
+
+// 为进程创建一个临时的cgroup
+func createCgroup(spec *Spec) (*cgroup.Cgroup, error) {
+	name := spec.Cgroup.Name
+	if name == "" {
+		name = uuid.NewV4().String()
+	}
+	return cgroup.New(spec.Cgroup.Root, name, cgroup.Limits{
+		MemoryLimit: spec.Limits.MemoryLimit,
+		PidsLimit:   spec.Cgroup.PidsLimit,
+		CPULimit:    spec.Cgroup.CPULimit,
+	})
+}
+
+// 杀死进程留下的所有进程，返回被杀死的进程数
+// 启用cgroup时以cgroup为准（可以覆盖调用了setsid/setpgid脱离进程组的进程），否则杀死整个进程组。
+// 隔离模式下进程是PID命名空间的1号进程，它退出时内核已经杀死了命名空间里的其他进程，这里统计到的数量为0
+func killProcessTree(cg *cgroup.Cgroup, pid int) int {
+	if cg != nil {
+		count, err := cg.Kill()
+		_ = syscall.Kill(-pid, syscall.SIGKILL)
+		if err == nil {
+			return count
+		}
+	}
+	return killProcessGroup(pid)
+}
+
+// 统计CPU时间、内存和退出状态，sampled为采样到的VmHWM (KB)
+func (u *Usage) collect(strategy MemoryStrategy, sampled int) {
+	ru := u.Rusage
+	u.CPUTime = int(ru.Utime.Sec*1000 + int64(ru.Utime.Usec)/1000 + ru.Stime.Sec*1000 + int64(ru.Stime.Usec)/1000)
+	u.Memory, u.MemoryStrategy = u.measureMemory(strategy, sampled)
+	// 启用cgroup时，使用cgroup统计的CPU时间
+	if u.CgroupStat != nil {
+		u.CPUTime = u.CgroupStat.CPUTime
+		if u.CgroupStat.PidsPeak > u.PeakTasks {
+			u.PeakTasks = u.CgroupStat.PidsPeak
+		}
+	}
+	u.ExitCode = u.Status.ExitStatus()
+	if u.Status.Signaled() {
+		u.Signal = int(u.Status.Signal())
+	}
+}
+
+// 判断进程触发了哪一个资源限制
+func (u *Usage) detectLimit(spec *Spec, flags *runFlags) {
+	limits := spec.Limits
+	if u.RestrictedSyscall != "" {
+		u.LimitHit = LimitSyscall
+	} else if flags.tasksExceeded || (u.CgroupStat != nil && u.CgroupStat.PidsLimited) {
+		u.LimitHit = LimitProcess
+	} else if flags.realTimeExceeded {
+		u.hitTimeLimit(LimitRealTime, constants.TimeLimitKillerRealTime)
+	} else if flags.idle {
+		u.LimitHit = LimitIdleness
+	} else if flags.supervisorKilled {
+		u.hitTimeLimit(LimitTime, constants.TimeLimitKillerSupervisor)
+	} else if isOutputLimitExceeded(spec) {
+		// 程序忽略了SIGXFSZ（或者作为PID命名空间里的1号进程收不到它），根据输出文件的大小判定
+		u.LimitHit = LimitOutput
+	} else if u.CgroupStat != nil {
+		// MLE只由memory.events判定，不再需要根据信号去猜测
+		if u.CgroupStat.OOMKilled {
+			u.LimitHit = LimitMemory
+		} else if u.Status.Signaled() {
+			switch u.Status.Signal() {
+			case syscall.SIGXFSZ:
+				u.LimitHit = LimitOutput
+			case syscall.SIGALRM:
+				u.hitTimeLimit(LimitRealTime, constants.TimeLimitKillerRealTime)
+			case syscall.SIGVTALRM, syscall.SIGXCPU, syscall.SIGKILL:
+				// 没有发生OOM的SIGKILL只能来自RLIMIT_CPU的硬限制
+				u.hitTimeLimit(LimitTime, constants.TimeLimitKillerRLimit)
+			}
+		} else {
+			u.detectLimitByUsage(&limits)
+		}
+	} else if u.Status.Signaled() {
+		switch u.Status.Signal() {
+		case syscall.SIGSEGV:
+			// MLE or RE can also get SIGSEGV signal.
+			if limits.MemoryLimit > 0 && u.Memory > limits.MemoryLimit {
+				u.LimitHit = LimitMemory
+			}
+		case syscall.SIGXFSZ:
+			u.LimitHit = LimitOutput
+		case syscall.SIGALRM:
+			u.hitTimeLimit(LimitRealTime, constants.TimeLimitKillerRealTime)
+		case syscall.SIGVTALRM, syscall.SIGXCPU:
+			u.hitTimeLimit(LimitTime, constants.TimeLimitKillerRLimit)
+		case syscall.SIGKILL:
+			// Sometimes MLE might get SIGKILL signal.
+			// So if real time used lower than TIME_LIMIT - 100, it might be a TLE error.
+			if limits.TimeLimit > 0 && u.CPUTime > limits.TimeLimit-100 {
+				u.hitTimeLimit(LimitTime, constants.TimeLimitKillerRLimit)
+			} else if limits.MemoryLimit > 0 {
+				u.LimitHit = LimitMemory
+			}
+		}
+	} else {
+		// Sometimes setrlimit doesn't work accurately.
+		u.detectLimitByUsage(&limits)
+	}
+}
+
+func (u *Usage) hitTimeLimit(limit Limit, killer string) {
+	u.LimitHit = limit
+	u.TimeLimitKiller = killer
+}
+
+// 正常退出的进程，根据统计到的资源使用情况判定
+func (u *Usage) detectLimitByUsage(limits *forkexec.ExecRLimit) {
+	if limits.TimeLimit > 0 && u.CPUTime > limits.TimeLimit {
+		u.LimitHit = LimitTime
+	} else if limits.MemoryLimit > 0 && u.Memory > limits.MemoryLimit {
+		u.LimitHit = LimitMemory
+	}
+}
+
+// 判断进程的输出（stdout是普通文件时）是否达到了文件大小限制
+func isOutputLimitExceeded(spec *Spec) bool {
+	if spec.Limits.FileSizeLimit <= 0 || len(spec.Files) < 2 {
+		return false
+	}
+	stdout, ok := spec.Files[1].(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := stdout.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	return info.Size() >= int64(spec.Limits.FileSizeLimit)
+}
@@ -0,0 +1,175 @@
+//go:build linux && amd64
+// +build linux,amd64
+
+package seccomp
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"syscall"
+)
+
+// seccomp的返回值
+const (
+	retKillProcess = 0x80000000
+	retErrno       = 0x00050000
+	retTrace       = 0x7ff00000
+	retAllow       = 0x7fff0000
+)
+
+const (
+	auditArchX8664 = 0xc000003e
+	// x32 ABI的系统调用号带有这个标志位，拦截掉防止绕过过滤规则
+	x32SyscallBit = 0x40000000
+)
+
+// struct seccomp_data 各字段的偏移量
+const (
+	offsetNr   = 0
+	offsetArch = 4
+	offsetArgs = 16
+)
+
+// 以写方式打开文件的标志位 (O_WRONLY | O_RDWR | O_CREAT)
+const openWriteFlags = 0x1 | 0x2 | 0x40
+
+var syscallNames map[int]string
+
+func init() {
+	syscallNames = make(map[int]string, len(syscallNumbers))
+	for name, nr := range syscallNumbers {
+		syscallNames[nr] = name
+	}
+}
+
+// SyscallName 获取系统调用的名称
+func SyscallName(nr int) string {
+	if name, ok := syscallNames[nr]; ok {
+		return name
+	}
+	return fmt.Sprintf("syscall_%d", nr)
+}
+
+func stmt(code uint16, k uint32) syscall.SockFilter {
+	return syscall.SockFilter{Code: code, K: k}
+}
+
+func jump(code uint16, k uint32, jt, jf uint8) syscall.SockFilter {
+	return syscall.SockFilter{Code: code, Jt: jt, Jf: jf, K: k}
+}
+
+func loadNr() syscall.SockFilter {
+	return stmt(syscall.BPF_LD|syscall.BPF_W|syscall.BPF_ABS, offsetNr)
+}
+
+func loadArg(index int, high bool) syscall.SockFilter {
+	offset := uint32(offsetArgs + 8*index)
+	if high {
+		offset += 4
+	}
+	return stmt(syscall.BPF_LD|syscall.BPF_W|syscall.BPF_ABS, offset)
+}
+
+func ret(action uint32) syscall.SockFilter {
+	return stmt(syscall.BPF_RET|syscall.BPF_K, action)
+}
+
+func lookup(name string) (uint32, error) {
+	nr, ok := syscallNumbers[name]
+	if !ok {
+		return 0, errors.Errorf("unknown syscall: %s", name)
+	}
+	return uint32(nr), nil
+}
+
+// Build 生成BPF过滤程序
+// execArg 是启动评测程序时传给execve的第一个参数（路径字符串的指针），参数不同的execve会被拦截。
+// 评测程序可以把路径放到同一个地址上绕过这个检查，所以跟踪进程还会在第二次exec成功时杀死进程（见cmd.WaitSeccomp）。
+// 过滤程序要在fork之前生成好，子进程里不能再分配内存。
+func (p *Profile) Build(execArg uintptr) ([]syscall.SockFilter, error) {
+	// 检查架构，再拦截x32 ABI的调用
+	filter := []syscall.SockFilter{
+		stmt(syscall.BPF_LD|syscall.BPF_W|syscall.BPF_ABS, offsetArch),
+		jump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, auditArchX8664, 1, 0),
+		ret(retKillProcess),
+		loadNr(),
+		jump(syscall.BPF_JMP|syscall.BPF_JGE|syscall.BPF_K, x32SyscallBit, 0, 1),
+		ret(retKillProcess),
+	}
+
+	// 只放行启动评测程序本身的那次execve（之后的exec由跟踪进程拦截）
+	execve, _ := lookup("execve")
+	filter = append(filter,
+		jump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, execve, 0, 6),
+		loadArg(0, false),
+		jump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, uint32(execArg), 0, 3),
+		loadArg(0, true),
+		jump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, uint32(uint64(execArg)>>32), 0, 1),
+		ret(retAllow),
+		ret(retTrace),
+	)
+	// 上面的分支会修改累加器，这里重新载入系统调用号
+	filter = append(filter, loadNr())
+
+	for _, name := range unsupportedList {
+		nr, err := lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		filter = append(filter,
+			jump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, nr, 0, 1),
+			ret(retErrno|uint32(syscall.ENOSYS)),
+		)
+	}
+
+	if p.DenyFork {
+		clone, _ := lookup("clone")
+		filter = append(filter,
+			jump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, clone, 0, 4),
+			loadArg(0, false),
+			jump(syscall.BPF_JMP|syscall.BPF_JSET|syscall.BPF_K, syscall.CLONE_THREAD, 1, 0),
+			ret(retTrace),
+			loadNr(),
+		)
+	}
+
+	if p.ReadOnlyOpen {
+		// open(path, flags, mode) / openat(dirfd, path, flags, mode)
+		for _, rule := range []struct {
+			name     string
+			flagsArg int
+		}{{"open", 1}, {"openat", 2}} {
+			nr, _ := lookup(rule.name)
+			filter = append(filter,
+				jump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, nr, 0, 4),
+				loadArg(rule.flagsArg, false),
+				jump(syscall.BPF_JMP|syscall.BPF_JSET|syscall.BPF_K, openWriteFlags, 0, 1),
+				ret(retErrno|uint32(syscall.EACCES)),
+				loadNr(),
+			)
+		}
+	}
+
+	// 白名单模式：命中放行，否则拦截；黑名单模式：命中拦截，否则放行
+	rules, match, otherwise := p.Allow, uint32(retAllow), uint32(retTrace)
+	if len(p.Allow) == 0 {
+		rules, match, otherwise = p.Deny, retTrace, retAllow
+	}
+	for _, name := range rules {
+		nr, err := lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		filter = append(filter,
+			jump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, nr, 0, 1),
+			ret(match),
+		)
+	}
+	filter = append(filter, ret(otherwise))
+
+	// BPF_MAXINSNS
+	if len(filter) > 4096 {
+		return nil, errors.Errorf("seccomp filter is too long")
+	}
+	return filter, nil
+}
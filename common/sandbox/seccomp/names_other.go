@@ -0,0 +1,11 @@
+//go:build !linux || !amd64
+// +build !linux !amd64
+
+package seccomp
+
+import "fmt"
+
+// SyscallName 获取系统调用的名称（当前平台没有系统调用表）
+func SyscallName(nr int) string {
+	return fmt.Sprintf("syscall_%d", nr)
+}
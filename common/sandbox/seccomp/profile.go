@@ -0,0 +1,105 @@
+package seccomp
+
+import "github.com/pkg/errors"
+
+// 内置的系统调用过滤规则名称
+const (
+	ProfileNone        = ""            // 不过滤
+	ProfileStrict      = "strict"      // C/C++/Rust等编译型语言，白名单模式
+	ProfileJVM         = "jvm"         // JVM及其他自带多线程运行时的语言(Java, Go)，黑名单模式
+	ProfileInterpreter = "interpreter" // 解释型语言(Python, PHP, Ruby, NodeJS)，黑名单模式
+)
+
+// Profile 系统调用过滤规则
+// 被拦截的系统调用会让内核通知跟踪进程(ptrace)，由评测机杀死进程并给出Restricted Function结果。
+// 第一次execve（即启动评测程序本身）总是被允许的，之后的execve/execveat一律拦截。
+type Profile struct {
+	Name string
+	// 白名单，不为空时只允许名单里的系统调用
+	Allow []string
+	// 黑名单，白名单为空时生效，拦截名单里的系统调用
+	Deny []string
+	// 拦截不带CLONE_THREAD标志的clone，即禁止fork出新的进程（创建线程不受影响）
+	DenyFork bool
+	// 以写方式打开文件时返回EACCES（不算作违规，程序可以自行处理）
+	ReadOnlyOpen bool
+}
+
+// 直接返回ENOSYS的系统调用（不算作违规）
+// clone3的参数是结构体指针，无法检查flags；io_uring可以绕过seccomp发起网络请求。
+// glibc和libuv在收到ENOSYS后都会退回到旧的实现。
+var unsupportedList = []string{"clone3", "io_uring_setup"}
+
+// 编译型语言需要用到的系统调用
+var strictAllowList = []string{
+	// 读写
+	"read", "write", "readv", "writev", "pread64", "pwrite64", "lseek",
+	"open", "openat", "close", "dup", "dup2", "dup3", "fcntl", "ioctl", "poll", "ppoll",
+	"stat", "fstat", "lstat", "newfstatat", "statx", "access", "faccessat", "faccessat2",
+	"readlink", "readlinkat", "getcwd",
+	// 内存
+	"brk", "mmap", "munmap", "mremap", "mprotect", "madvise",
+	// 信号
+	"rt_sigaction", "rt_sigprocmask", "rt_sigreturn", "sigaltstack", "tgkill",
+	// 进程运行环境
+	"arch_prctl", "set_tid_address", "set_robust_list", "rseq", "futex",
+	"getrlimit", "prlimit64", "getrandom", "uname", "sysinfo",
+	"getpid", "gettid", "getuid", "geteuid", "getgid", "getegid",
+	"sched_yield", "sched_getaffinity",
+	// 时间
+	"time", "gettimeofday", "clock_gettime", "clock_getres", "clock_nanosleep", "nanosleep",
+	"times", "getrusage",
+	// 退出
+	"exit", "exit_group",
+}
+
+// 托管运行时和解释器不允许使用的系统调用
+var runtimeDenyList = []string{
+	// 进程
+	"execve", "execveat", "fork", "vfork", "kill", "ptrace",
+	"process_vm_readv", "process_vm_writev", "pidfd_open", "pidfd_getfd", "pidfd_send_signal",
+	// 网络
+	"socket", "socketpair", "connect", "bind", "listen", "accept", "accept4",
+	"sendto", "sendmsg", "sendmmsg", "recvfrom", "recvmsg", "recvmmsg",
+	// 系统管理
+	"mount", "umount2", "pivot_root", "chroot", "unshare", "setns",
+	"setuid", "setgid", "setreuid", "setregid", "setresuid", "setresgid", "setgroups",
+	"reboot", "kexec_load", "kexec_file_load", "init_module", "finit_module", "delete_module",
+	"swapon", "swapoff", "sethostname", "setdomainname", "settimeofday", "clock_settime", "adjtimex",
+	"iopl", "ioperm", "acct", "quotactl", "mknod", "mknodat",
+	"bpf", "perf_event_open", "userfaultfd", "keyctl", "add_key", "request_key",
+	"name_to_handle_at", "open_by_handle_at",
+}
+
+// Profiles 内置的过滤规则
+var Profiles = map[string]*Profile{
+	ProfileStrict: {
+		Name:         ProfileStrict,
+		Allow:        strictAllowList,
+		ReadOnlyOpen: true,
+	},
+	ProfileJVM: {
+		// JVM会在/tmp/hsperfdata_*下写性能计数文件，所以不限制写文件
+		Name:     ProfileJVM,
+		Deny:     runtimeDenyList,
+		DenyFork: true,
+	},
+	ProfileInterpreter: {
+		Name:         ProfileInterpreter,
+		Deny:         runtimeDenyList,
+		DenyFork:     true,
+		ReadOnlyOpen: true,
+	},
+}
+
+// GetProfile 根据名称获取过滤规则，名称为空时返回nil（不过滤）
+func GetProfile(name string) (*Profile, error) {
+	if name == ProfileNone {
+		return nil, nil
+	}
+	profile, ok := Profiles[name]
+	if !ok {
+		return nil, errors.Errorf("unknown seccomp profile: %s", name)
+	}
+	return profile, nil
+}
@@ -0,0 +1,184 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package sandbox
+
+import (
+	"github.com/LanceLRQ/deer-executor/v2/common/constants"
+	"github.com/LanceLRQ/deer-executor/v2/common/sandbox/cgroup"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// 真实时间看门狗
+type realTimeWatchdog struct {
+	timer *time.Timer
+	fired int32
+}
+
+// 超出真实时间限制(ms)后由评测机杀死进程组
+func watchRealTime(limit, pid int) *realTimeWatchdog {
+	if limit <= 0 {
+		return nil
+	}
+	w := &realTimeWatchdog{}
+	w.timer = time.AfterFunc(time.Duration(limit)*time.Millisecond, func() {
+		atomic.StoreInt32(&w.fired, 1)
+		_ = syscall.Kill(-pid, syscall.SIGKILL)
+	})
+	return w
+}
+
+// 停止看门狗，返回进程是否是被它杀死的
+func (w *realTimeWatchdog) stop() bool {
+	if w == nil {
+		return false
+	}
+	w.timer.Stop()
+	return atomic.LoadInt32(&w.fired) == 1
+}
+
+// CPU时间监控
+type cpuSupervisor struct {
+	quit  chan struct{}
+	done  chan struct{}
+	fired bool // 超出了CPU时间限制
+	idle  bool // 超出了空闲时间限制
+	used  int
+}
+
+// RLIMIT_CPU只能精确到秒，由评测机按照设定的间隔轮询进程的CPU时间，超出时间限制(ms)后立即杀死它。
+// 同时检测空闲：真实时间在增长而CPU时间长时间没有变化（如阻塞在stdin上），超出空闲时间限制后也会杀死它。
+// 启用cgroup时读取cpu.stat（包含所有子进程），否则读取/proc下的进程信息
+func superviseCPUTime(spec *Spec, cg *cgroup.Cgroup, pid int) *cpuSupervisor {
+	limit := spec.Limits.TimeLimit
+	interval := spec.SupervisorInterval
+	idleLimit := spec.IdlenessLimit
+	if interval <= 0 {
+		// 没有启用CPU时间监控，只检测空闲
+		limit = 0
+		interval = constants.CPUSupervisorInterval
+	}
+	if limit <= 0 && idleLimit <= 0 {
+		return nil
+	}
+	s := &cpuSupervisor{
+		quit: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(time.Duration(interval) * time.Millisecond)
+		defer ticker.Stop()
+		lastChanged := time.Now()
+		for {
+			select {
+			case <-s.quit:
+				return
+			case <-ticker.C:
+			}
+			var used int
+			var err error
+			if cg != nil {
+				used, err = cg.CPUTime()
+			} else {
+				used, err = readProcessCPUTime(pid)
+			}
+			if err != nil {
+				// 进程已经退出
+				return
+			}
+			if used != s.used {
+				lastChanged = time.Now()
+			}
+			s.used = used
+			if limit > 0 && used > limit {
+				s.fired = true
+			} else if idleLimit > 0 && time.Since(lastChanged) > time.Duration(idleLimit)*time.Millisecond {
+				s.idle = true
+			} else {
+				continue
+			}
+			_ = syscall.Kill(-pid, syscall.SIGKILL)
+			return
+		}
+	}()
+	return s
+}
+
+// 停止监控，把结果记录下来
+func (s *cpuSupervisor) stop(usage *Usage, flags *runFlags) {
+	if s == nil {
+		return
+	}
+	close(s.quit)
+	<-s.done
+	flags.supervisorKilled = s.fired
+	flags.idle = s.idle
+	usage.SupervisorTimeUsed = s.used
+}
+
+// 进程/线程数监控
+type taskMonitor struct {
+	quit  chan struct{}
+	done  chan struct{}
+	fired bool // 超出了进程/线程数限制
+	peak  int  // 同时存在的进程/线程数峰值
+}
+
+// 按固定的间隔统计进程组（启用cgroup时为整个cgroup）里的进程/线程数并记录峰值，limit大于0时超出限制后杀死进程组。
+// 只在内核无法限制（没有启用cgroup，并且以评测机自己的身份运行）时才需要传入limit，这时的限制是尽力而为的：
+// 两次统计之间可以创建大量的进程/线程，而且进程越多，每次扫描/proc越慢；
+// 同样由于是轮询，两次统计之间创建又退出的进程/线程不会被计入峰值。既不需要限制也不需要峰值时不启动监控
+func monitorTasks(limit int, report bool, cg *cgroup.Cgroup, pid int) *taskMonitor {
+	if limit <= 0 && !report {
+		return nil
+	}
+	m := &taskMonitor{
+		quit: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	count := func() (int, error) {
+		if cg != nil {
+			return cg.Tasks()
+		}
+		return countProcessGroupTasks(pid)
+	}
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(constants.TaskMonitorInterval * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			tasks, err := count()
+			if err != nil {
+				return
+			}
+			if tasks > m.peak {
+				m.peak = tasks
+			}
+			if limit > 0 && tasks > limit {
+				m.fired = true
+				_ = syscall.Kill(-pid, syscall.SIGKILL)
+				return
+			}
+			select {
+			case <-m.quit:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return m
+}
+
+// 停止监控，把结果记录下来
+func (m *taskMonitor) stop(usage *Usage, flags *runFlags) {
+	if m == nil {
+		return
+	}
+	close(m.quit)
+	<-m.done
+	flags.tasksExceeded = m.fired
+	usage.PeakTasks = m.peak
+}
@@ -0,0 +1,33 @@
+//go:build darwin
+// +build darwin
+
+package sandbox
+
+import (
+	"github.com/LanceLRQ/deer-executor/v2/common/sandbox/forkexec"
+	"github.com/pkg/errors"
+)
+
+// 让进程运行在新的命名空间和只读的根文件系统里（仅Linux支持）
+func applyIsolation(spec *Spec, sys *forkexec.SysProcAttr) (bool, error) {
+	if spec.Isolation != nil {
+		return false, errors.Errorf("namespace isolation is not supported on darwin")
+	}
+	return false, nil
+}
+
+// 把进程绑定到指定的CPU核心上（仅Linux支持）
+func applyAffinity(spec *Spec, sys *forkexec.SysProcAttr) error {
+	if len(spec.CPUs) > 0 {
+		return errors.Errorf("cpu affinity is not supported on darwin")
+	}
+	return nil
+}
+
+// 开启系统调用过滤（仅Linux支持）
+func applySeccomp(spec *Spec, sys *forkexec.SysProcAttr) (bool, error) {
+	if spec.Seccomp != nil {
+		return false, errors.Errorf("seccomp is not supported on darwin")
+	}
+	return false, nil
+}
@@ -0,0 +1,81 @@
+//go:build linux
+// +build linux
+
+package sandbox
+
+import (
+	"github.com/LanceLRQ/deer-executor/v2/common/sandbox/forkexec"
+	"github.com/pkg/errors"
+	"os"
+	"path"
+	"syscall"
+)
+
+// 隔离模式下nobody/nogroup的id
+const isolationNobody = 65534
+
+// 新根目录的挂载点
+// tmpfs只挂在子进程自己的挂载命名空间里，所以所有进程可以共用同一个空目录
+var isolationRoot = path.Join(os.TempDir(), "deer-executor-rootfs")
+
+// 让进程运行在新的命名空间和只读的根文件系统里，返回是否启用了隔离
+func applyIsolation(spec *Spec, sys *forkexec.SysProcAttr) (bool, error) {
+	opts := spec.Isolation
+	if opts == nil {
+		return false, nil
+	}
+	if err := os.MkdirAll(isolationRoot, 0755); err != nil {
+		return false, errors.Errorf("create isolation root error: %s", err.Error())
+	}
+	mounts := make([]forkexec.BindMount, 0, len(opts.Mounts))
+	for _, m := range opts.Mounts {
+		mounts = append(mounts, forkexec.BindMount{Source: m.Source, Target: m.Target, Writable: m.Writable})
+	}
+	sys.RootFS = &forkexec.RootFS{
+		Root:   isolationRoot,
+		Mounts: mounts,
+		Proc:   true,
+	}
+	sys.Cloneflags |= syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWPID |
+		syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS | syscall.CLONE_NEWNET
+
+	uid, gid := opts.UID, opts.GID
+	if uid <= 0 {
+		// 没有单独设置时，使用Spec里的用户
+		uid, gid = spec.credential()
+	}
+	if uid <= 0 {
+		uid = isolationNobody
+	}
+	if gid <= 0 {
+		gid = isolationNobody
+	}
+	// 只映射运行程序的用户，命名空间里的root没有对应的宿主机用户，逃出命名空间也拿不到宿主机root的身份
+	sys.RootFS.UID, sys.RootFS.GID = uid, gid
+	sys.UidMappings = []syscall.SysProcIDMap{
+		{ContainerID: uid, HostID: uid, Size: 1},
+	}
+	sys.GidMappings = []syscall.SysProcIDMap{
+		{ContainerID: gid, HostID: gid, Size: 1},
+	}
+	sys.GidMappingsEnableSetgroups = true
+	sys.Credential = &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}
+	return true, nil
+}
+
+// 把进程绑定到指定的CPU核心上，它fork出来的进程和创建的线程也会继承这个设置
+func applyAffinity(spec *Spec, sys *forkexec.SysProcAttr) error {
+	sys.CPUAffinity = spec.CPUs
+	return nil
+}
+
+// 开启系统调用过滤，返回是否需要以跟踪模式等待进程退出
+func applySeccomp(spec *Spec, sys *forkexec.SysProcAttr) (bool, error) {
+	if spec.Seccomp == nil {
+		return false, nil
+	}
+	sys.Seccomp = spec.Seccomp
+	// 被拦截的系统调用交给评测机处理（进程总是单独一个进程组，方便等待它的所有线程）
+	sys.Ptrace = true
+	return true, nil
+}
@@ -0,0 +1,107 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package sandbox
+
+import (
+	"github.com/LanceLRQ/deer-executor/v2/common/constants"
+	"github.com/LanceLRQ/deer-executor/v2/common/sandbox/cgroup"
+	"time"
+)
+
+// UsageSample 资源使用时间线上的一个采样点
+type UsageSample struct {
+	Time    int `json:"time"`     // 从进程启动开始经过的真实时间 (ms)
+	CPUTime int `json:"cpu_time"` // 已经使用的CPU时间 (ms)
+	RSS     int `json:"rss"`      // 当前的常驻内存 (KB)
+}
+
+// 资源使用时间线采样
+type timelineSampler struct {
+	quit    chan struct{}
+	done    chan struct{}
+	samples []UsageSample
+}
+
+// 按固定的间隔记录进程的CPU时间和常驻内存（启用cgroup时CPU时间来自cpu.stat，常驻内存只统计主进程）。
+// 采样点数达到上限后丢掉一半的采样点并把间隔加倍，所以无论程序运行多久，时间线总是覆盖整个运行过程
+func sampleTimeline(spec *Spec, cg *cgroup.Cgroup, pid int, startTime time.Time) *timelineSampler {
+	interval := spec.TimelineInterval
+	if interval <= 0 {
+		return nil
+	}
+	limit := spec.TimelineLimit
+	if limit <= 0 {
+		limit = constants.TimelineMaxSamples
+	}
+	if limit < 2 {
+		limit = 2
+	}
+	s := &timelineSampler{
+		quit:    make(chan struct{}),
+		done:    make(chan struct{}),
+		samples: make([]UsageSample, 0, limit),
+	}
+	go func() {
+		defer close(s.done)
+		period := time.Duration(interval) * time.Millisecond
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+		for {
+			var used int
+			var err error
+			if cg != nil {
+				used, err = cg.CPUTime()
+			} else {
+				used, err = readProcessCPUTime(pid)
+			}
+			if err != nil {
+				// 进程已经退出
+				return
+			}
+			rss, _, err := readProcessMemory(pid)
+			if err != nil {
+				return
+			}
+			s.samples = append(s.samples, UsageSample{
+				Time:    int(time.Since(startTime) / time.Millisecond),
+				CPUTime: used,
+				RSS:     rss,
+			})
+			if len(s.samples) >= limit {
+				s.samples = halveSamples(s.samples)
+				period *= 2
+				ticker.Reset(period)
+			}
+			select {
+			case <-s.quit:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return s
+}
+
+// 只保留偶数位置上的采样点
+func halveSamples(samples []UsageSample) []UsageSample {
+	n := 0
+	for i := 0; i < len(samples); i += 2 {
+		samples[n] = samples[i]
+		n++
+	}
+	return samples[:n]
+}
+
+// 停止采样，返回时间线
+func (s *timelineSampler) stop() []UsageSample {
+	if s == nil {
+		return nil
+	}
+	close(s.quit)
+	<-s.done
+	if len(s.samples) == 0 {
+		return nil
+	}
+	return s.samples
+}
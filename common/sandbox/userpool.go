@@ -0,0 +1,53 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package sandbox
+
+import (
+	"context"
+	"github.com/pkg/errors"
+)
+
+// User 运行进程的用户
+type User struct {
+	UID int
+	GID int
+}
+
+// UserPool 非特权用户池
+// 每个评测会话租用一个不同的用户运行目标程序和判题程序，结束后杀死该用户残留的进程再归还
+type UserPool struct {
+	users chan User
+}
+
+// NewUserPool 创建用户池，uid和gid分别从uidStart和gidStart开始连续分配size个，gidStart为0时gid与uid相同
+func NewUserPool(uidStart, gidStart, size int) (*UserPool, error) {
+	if uidStart <= 0 || size <= 0 {
+		return nil, errors.Errorf("invalid user pool: uid start %d, size %d", uidStart, size)
+	}
+	if gidStart <= 0 {
+		gidStart = uidStart
+	}
+	pool := &UserPool{users: make(chan User, size)}
+	for i := 0; i < size; i++ {
+		pool.users <- User{UID: uidStart + i, GID: gidStart + i}
+	}
+	return pool, nil
+}
+
+// Acquire 租用一个用户，没有空闲的用户时阻塞到有用户被归还为止；ctx被取消时返回ctx.Err()
+func (pool *UserPool) Acquire(ctx context.Context) (User, error) {
+	select {
+	case user := <-pool.users:
+		return user, nil
+	case <-ctx.Done():
+		return User{}, ctx.Err()
+	}
+}
+
+// Release 杀死用户残留的所有进程并归还用户，返回被杀死的进程数
+func (pool *UserPool) Release(user User) int {
+	count := KillUserProcesses(user.UID)
+	pool.users <- user
+	return count
+}
@@ -7,19 +7,66 @@ import (
 
 // JudgeConfiguration 评测配置信息
 type JudgeConfiguration struct {
-	TestCases     []TestCase                    `json:"test_cases"`      // Test cases
-	TimeLimit     int                           `json:"time_limit"`      // Time limit (ms)
-	MemoryLimit   int                           `json:"memory_limit"`    // Memory limit (KB)
-	RealTimeLimit int                           `json:"real_time_limit"` // Real Time Limit (ms) (optional)
-	FileSizeLimit int                           `json:"file_size_limit"` // File Size Limit (bytes) (optional)
-	UID           int                           `json:"uid"`             // User id (optional)
-	StrictMode    bool                          `json:"strict_mode"`     // Strict Mode (if close, PE will be ignore)
-	SpecialJudge  SpecialJudgeOptions           `json:"special_judge"`   // Special Judge Options
-	Limitation    map[string]JudgeResourceLimit `json:"limitation"`      // Limitation
-	Problem       ProblemContent                `json:"problem"`         // Problem Info
-	TestLib       TestlibOptions                `json:"testlib"`         // testlib设置
-	AnswerCases   []AnswerCase                  `json:"answer_cases"`    // Answer cases (用于生成Output)
-	ConfigDir     string                        `json:"-"`               // 内部字段：config文件所在目录绝对路径
+	TestCases      []TestCase                    `json:"test_cases"`       // Test cases
+	TimeLimit      int                           `json:"time_limit"`       // Time limit (ms)
+	MemoryLimit    int                           `json:"memory_limit"`     // Memory limit (KB)
+	RealTimeLimit  int                           `json:"real_time_limit"`  // Real Time Limit (ms) (optional)
+	FileSizeLimit  int                           `json:"file_size_limit"`  // File Size Limit (bytes) (optional)
+	ProcessLimit   int                           `json:"process_limit"`    // Process/thread count limit (optional, 0 means language default, -1 means unlimited)
+	StackLimit     int                           `json:"stack_limit"`      // Stack size limit (KB) (optional, 0 means twice the memory limit, -1 means unlimited)
+	OpenFilesLimit int                           `json:"open_files_limit"` // Open files limit (optional, 0 means unlimited)
+	CoreDump       bool                          `json:"core_dump"`        // Allow core dump (optional, disabled by default)
+	UID            int                           `json:"uid"`              // User id (optional)
+	StrictMode     bool                          `json:"strict_mode"`      // Strict Mode (if close, PE will be ignore)
+	SpecialJudge   SpecialJudgeOptions           `json:"special_judge"`    // Special Judge Options
+	Limitation     map[string]JudgeResourceLimit `json:"limitation"`       // Limitation
+	Problem        ProblemContent                `json:"problem"`          // Problem Info
+	TestLib        TestlibOptions                `json:"testlib"`          // testlib设置
+	AnswerCases    []AnswerCase                  `json:"answer_cases"`     // Answer cases (用于生成Output)
+	Environment    EnvironmentPolicy             `json:"environment"`      // 目标程序的环境变量策略（与语言的策略合并）
+	Subtasks       []Subtask                     `json:"subtasks"`         // 子任务（可选，设置后按子任务计分）
+	JudgePolicy    JudgePolicy                   `json:"judge_policy"`     // 评测策略（可选，不设置时由strict_mode决定）
+	Compare        CompareOptions                `json:"compare"`          // 文本比较方式（可选，默认逐字符比较）
+	OutputOnly     bool                          `json:"output_only"`      // 提交答案题：提交的是按测试数据的Handle命名的输出文件（zip压缩包或目录），不编译也不运行程序
+	ConfigDir      string                        `json:"-"`                // 内部字段：config文件所在目录绝对路径
+}
+
+// EnvironmentPolicy 目标程序的环境变量策略
+// 目标程序默认运行在空的环境变量里，另外总是会注入ONLINE_JUDGE=1和RANDOM_SEED
+type EnvironmentPolicy struct {
+	Inherit    []string          `json:"inherit"`     // 允许从评测机继承的环境变量名（白名单）
+	Fixed      map[string]string `json:"fixed"`       // 固定值的环境变量
+	RandomSeed int64             `json:"random_seed"` // 随机数种子，0表示根据测试数据的Handle生成
+}
+
+// JudgePolicy 评测策略：什么时候结束评测，以及怎样计算最终结果
+// 为空的设置项按strict_mode取默认值：严格模式下遇到第一组失败的测试数据就结束评测，PE作为最终结果但不结束评测；
+// 非严格模式下WA之后继续评测，PE视为AC。设置了子任务时各子任务自行决定是否继续，只有PE的处理方式和最终结果的计算方式有效
+type JudgePolicy struct {
+	OnFailure         string `json:"on_failure"`         // stop (stop at the first failed case) or run_all (keep judging after failures)
+	PresentationError string `json:"presentation_error"` // accept (as AC), fail (a failed case) or continue (a failed case that never stops judging)
+	ContinueOnTLE     bool   `json:"continue_on_tle"`    // Keep judging after TLE/ILE in run_all mode (stop by default)
+	ContinueOnRE      bool   `json:"continue_on_re"`     // Keep judging after RE/MLE/OLE/RF in run_all mode (stop by default)
+	MaxFailures       int    `json:"max_failures"`       // Stop after this many failed cases (optional, 0 means unlimited)
+	Verdict           string `json:"verdict"`            // How the final verdict is computed: worst (the most severe verdict, default) or first (the first failed case)
+}
+
+// CompareOptions 文本比较方式
+// float模式下按空白字符切分单词，参考答案里的数字允许有一定的误差（满足绝对误差或者相对误差其中一个即可），其他单词必须完全一致
+type CompareOptions struct {
+	Mode            string  `json:"mode"`             // text (compare each char, default) or float (compare tokens, numbers with epsilon)
+	AbsoluteEpsilon float64 `json:"absolute_epsilon"` // Absolute epsilon of numbers (float mode, 1e-6 if both epsilons are 0)
+	RelativeEpsilon float64 `json:"relative_epsilon"` // Relative epsilon of numbers (float mode, 1e-6 if both epsilons are 0)
+}
+
+// Subtask 子任务：一组按同一个策略计分的测试数据
+// 设置了子任务的题目会评测所有子任务（不会因为某组测试数据出错而结束评测），没有被任何子任务引用的测试数据不会被评测
+type Subtask struct {
+	Name      string   `json:"name"`       // Subtask name (unique)
+	Score     float64  `json:"score"`      // Full score of the subtask
+	Policy    string   `json:"policy"`     // Scoring policy: all (all-or-nothing, default), min (score * minimum case score) or sum (score * average case score)
+	Cases     []string `json:"cases"`      // Handles of the test cases
+	DependsOn []string `json:"depends_on"` // Subtasks that must be passed before judging this one (optional)
 }
 
 // AnswerCase 答案代码样例
@@ -33,17 +80,18 @@ type AnswerCase struct {
 
 // TestCase 测试数据
 type TestCase struct {
-	Handle           string `json:"handle"`            // Identifier
-	Order            int    `json:"order"`             // Order (ASC)
-	Name             string `json:"name"`              // Testcase name
-	Input            string `json:"input"`             // Testcase input file path
-	Output           string `json:"output"`            // Testcase output file path
-	Visible          bool   `json:"visible"`           // Is visible(for oj)
-	Enabled          bool   `json:"enabled"`           // Is enabled
-	UseGenerator     bool   `json:"use_genarator"`     // Use generator
-	Generator        string `json:"generator"`         // Generator script
-	ValidatorVerdict bool   `json:"validator_verdict"` // Testlib validator's result
-	ValidatorComment string `json:"validator_comment"` // Testlib validator's output
+	Handle           string  `json:"handle"`            // Identifier
+	Order            int     `json:"order"`             // Order (ASC)
+	Name             string  `json:"name"`              // Testcase name
+	Input            string  `json:"input"`             // Testcase input file path
+	Output           string  `json:"output"`            // Testcase output file path
+	Visible          bool    `json:"visible"`           // Is visible(for oj)
+	Enabled          bool    `json:"enabled"`           // Is enabled
+	UseGenerator     bool    `json:"use_genarator"`     // Use generator
+	Generator        string  `json:"generator"`         // Generator script
+	ValidatorVerdict bool    `json:"validator_verdict"` // Testlib validator's result
+	ValidatorComment string  `json:"validator_comment"` // Testlib validator's output
+	Score            float64 `json:"score"`             // Score weight (optional, every case weighs 1 if none of the cases has a weight)
 }
 
 // SpecialJudgeOptions 特殊评测设置
@@ -57,6 +105,7 @@ type SpecialJudgeOptions struct {
 	MemoryLimit        int                       `json:"memory_limit"`         // Memory limit (kb)
 	UseTestlib         bool                      `json:"use_testlib"`          // If use testlib, checker will only support c++
 	CheckerCases       []SpecialJudgeCheckerCase `json:"checker_cases"`        // Special Judge checker cases (for Testlib, exclude interactor mode)
+	Builtin            string                    `json:"builtin"`              // Builtin checker name (wcmp, lcmp, ncmp, rcmpN, yesno, uwcmp), run in-process without compilation (checker mode only)
 }
 
 // SpecialJudgeCheckerCase 特判检查器样例
@@ -108,6 +157,44 @@ type JudgeResult struct {
 	SeInfo      string                `json:"se_info"`      // SeInfo when System Error
 	CeInfo      string                `json:"ce_info"`      // CeInfo when Compile Error
 	JudgeLogs   []logger.JudgeLogItem `json:"judge_logs"`   // Judge Logs
+	CPUCore     int                   `json:"cpu_core"`     // CPU core the programs were pinned to, -1 if not pinned
+	Score       float64               `json:"score"`        // Total score (sum of subtask scores, or weighted sum of case scores)
+	MaxScore    float64               `json:"max_score"`    // Full score
+	Subtasks    []SubtaskResult       `json:"subtasks"`     // Subtask results (in the order of configuration)
+}
+
+// SubtaskResult 子任务评测结果
+type SubtaskResult struct {
+	Name        string   `json:"name"`         // Subtask name
+	Score       float64  `json:"score"`        // Score got
+	MaxScore    float64  `json:"max_score"`    // Full score
+	Policy      string   `json:"policy"`       // Scoring policy
+	JudgeResult int      `json:"judge_result"` // Accepted if all cases passed, otherwise the verdict of the first failed case (or the failed dependency)
+	Cases       []string `json:"cases"`        // Handles of the judged test cases
+	Skipped     []string `json:"skipped"`      // Handles of the test cases skipped after the subtask failed
+	Dependency  string   `json:"dependency"`   // The failed dependency if the subtask was skipped because of it
+}
+
+// DiffReport 答案错误时的比较报告：第一处不一致的行以及它前后的几行
+// 隐藏的测试数据只报告行号，不包含数据的内容
+type DiffReport struct {
+	Line         int               `json:"line"`          // Line number of the first difference in the answer (1-based)
+	ActualLine   int               `json:"actual_line"`   // Line number of the first difference in the program output (1-based)
+	Column       int               `json:"column"`        // Byte offset of the first difference in the answer line (1-based)
+	ActualColumn int               `json:"actual_column"` // Byte offset of the first difference in the program output line (1-based)
+	Expected     string            `json:"expected"`      // Excerpt of the answer line (truncated, escaped)
+	Actual       string            `json:"actual"`        // Excerpt of the program output line (truncated, escaped)
+	Context      []DiffContextLine `json:"context"`       // A few lines before and after the first difference
+	Whitespace   bool              `json:"whitespace"`    // Whitespace is visualised in the excerpts (PE): space as '·', tab as '→', CR as '␍', line end as '↵'
+	Hidden       bool              `json:"hidden"`        // The test case is hidden, excerpts and context are withheld
+}
+
+// DiffContextLine 比较报告里的上下文，两边按照第一处不一致的行对齐
+type DiffContextLine struct {
+	Line       int    `json:"line"`        // Line number in the answer (0 if out of range)
+	ActualLine int    `json:"actual_line"` // Line number in the program output (0 if out of range)
+	Expected   string `json:"expected"`    // Excerpt of the answer line
+	Actual     string `json:"actual"`      // Excerpt of the program output line
 }
 
 // TestCaseResult 测试数据运行结果
@@ -115,6 +202,7 @@ type TestCaseResult struct {
 	Handle       string `json:"handle"`        // Identifier
 	Input        string `json:"-"`             // Testcase input file path (internal)
 	Output       string `json:"-"`             // Testcase output file path (internal)
+	Visible      bool   `json:"-"`             // Is the testcase visible (internal)
 	ProgramOut   string `json:"program_out"`   // Program-stdout file path
 	ProgramError string `json:"program_error"` // Program-stderr file path
 
@@ -122,8 +210,10 @@ type TestCaseResult struct {
 	CheckerError  string `json:"checker_error"`  // Special judge checker's stderr
 	CheckerReport string `json:"checker_report"` // Special judge checker's report file
 
-	JudgeResult    int `json:"judge_result"`    // Judge result flag number
-	PartiallyScore int `json:"partially_score"` // Testlib Partially Score or Math.floor(SameLines / TotalLines)
+	JudgeResult    int     `json:"judge_result"`    // Judge result flag number
+	PartiallyScore int     `json:"partially_score"` // Testlib Partially Score or Math.floor(SameLines / TotalLines)
+	Points         float64 `json:"points"`          // Points given by a testlib checker with quitp (relative to the case's score weight)
+	Score          float64 `json:"score"`           // Normalised score (0~1): 1 if passed, testlib partial points / 100, testlib points / weight, or the "score:" line of a classical checker's report
 
 	TextDiffLog string `json:"text_diff_log"` // Text Checkup Log
 	TimeUsed    int    `json:"time_used"`     // Maximum time used
@@ -135,19 +225,42 @@ type TestCaseResult struct {
 	SeInfo      string `json:"se_info"`       // SeInfo when System Error
 	CeInfo      string `json:"ce_info"`       // CeInfo when Compile Error
 
+	StrayProcesses     int    `json:"stray_processes"`      // Stray processes killed after the program exited
+	TimeLimitKiller    string `json:"time_limit_killer"`    // Who killed the program when TLE: supervisor, rlimit or real_time (empty if not killed)
+	SupervisorTimeUsed int    `json:"supervisor_time_used"` // CPU time measured by the supervisor (ms)
+	PeakTasks          int    `json:"peak_tasks"`           // Peak count of processes and threads running at the same time
+	MemoryStrategy     string `json:"memory_strategy"`      // How MemoryUsed was measured: minflt, maxrss, vmhwm or cgroup_peak
+
+	Timeline []UsageSample `json:"timeline"` // Resource usage sampled while the program was running (capped in length, empty if disabled)
+
+	DiffReport *DiffReport `json:"diff_report,omitempty"` // Mismatch report when WA or PE (text checker only)
+
 	SPJExitCode   int    `json:"spj_exit_code"`     // Special judge exit code
 	SPJTimeUsed   int    `json:"spj_time_used"`     // Special judge maximum time used
 	SPJMemoryUsed int    `json:"spj_memory_used"`   // Special judge maximum memory used
 	SPJReSignum   int    `json:"spj_re_signal_num"` // Special judge runtime error signal number
 	SPJMsg        string `json:"spj_msg"`           // Special judge checker  msg
+
+	SPJStrayProcesses int `json:"spj_stray_processes"` // Stray processes killed after the checker exited
+}
+
+// UsageSample 资源使用时间线上的一个采样点
+type UsageSample struct {
+	Time    int `json:"time"`     // Real time elapsed since the program started (ms)
+	CPUTime int `json:"cpu_time"` // CPU time used (ms)
+	RSS     int `json:"rss"`      // Resident memory (KB)
 }
 
 // JudgeResourceLimit 评测资源限制信息
 type JudgeResourceLimit struct {
-	TimeLimit     int `json:"time_limit"`      // Time limit (ms)
-	MemoryLimit   int `json:"memory_limit"`    // Memory limit (KB)
-	RealTimeLimit int `json:"real_time_limit"` // Real Time Limit (ms) (optional)
-	FileSizeLimit int `json:"file_size_limit"` // File Size Limit (bytes) (optional)
+	TimeLimit      int  `json:"time_limit"`       // Time limit (ms)
+	MemoryLimit    int  `json:"memory_limit"`     // Memory limit (KB)
+	RealTimeLimit  int  `json:"real_time_limit"`  // Real Time Limit (ms) (optional)
+	FileSizeLimit  int  `json:"file_size_limit"`  // File Size Limit (bytes) (optional)
+	ProcessLimit   int  `json:"process_limit"`    // Process/thread count limit (optional, 0 means language default, -1 means unlimited)
+	StackLimit     int  `json:"stack_limit"`      // Stack size limit (KB) (optional, 0 means twice the memory limit, -1 means unlimited)
+	OpenFilesLimit int  `json:"open_files_limit"` // Open files limit (optional, 0 means unlimited)
+	CoreDump       bool `json:"core_dump"`        // Allow core dump (optional, disabled by default)
 }
 
 // TestlibCheckerResult Testlib检查器报告
@@ -155,5 +268,6 @@ type TestlibCheckerResult struct {
 	XMLName     xml.Name `xml:"result"`
 	Outcome     string   `xml:"outcome,attr"`
 	PcType      string   `xml:"pctype,attr"`
+	Points      string   `xml:"points,attr"`
 	Description string   `xml:",innerxml"`
 }
@@ -0,0 +1,26 @@
+package structs
+
+// IsolationOptions 命名空间隔离设置
+// 启用后目标程序运行在新的user/mount/pid/ipc/uts/net命名空间里，
+// 根目录被替换为由Rootfs只读挂载组成的最小文件系统，只有会话目录可写。
+type IsolationOptions struct {
+	Enabled   bool                                `json:"enabled" mapstructure:"enabled"`     // 是否启用
+	UID       int                                 `json:"uid" mapstructure:"uid"`             // 运行目标程序的用户，0表示nobody(65534)
+	GID       int                                 `json:"gid" mapstructure:"gid"`             // 运行目标程序的用户组，0表示nogroup(65534)
+	Rootfs    []string                            `json:"rootfs" mapstructure:"rootfs"`       // 所有语言都需要只读挂载的宿主机路径
+	Languages map[string]IsolationLanguageOptions `json:"languages" mapstructure:"languages"` // 按编译器提供程序名称(gcc, java, python3...)单独设置
+}
+
+// IsolationLanguageOptions 单个语言的隔离设置
+type IsolationLanguageOptions struct {
+	Disabled bool     `json:"disabled" mapstructure:"disabled"` // 该语言不启用隔离
+	Rootfs   []string `json:"rootfs" mapstructure:"rootfs"`     // 额外只读挂载的宿主机路径（如语言运行时的安装目录）
+}
+
+// UserPoolOptions 非特权用户池设置
+// 启用后每个评测会话租用一个不同的uid/gid运行目标程序和判题程序，会话目录的所有者也会改为该用户
+type UserPoolOptions struct {
+	UIDStart int `json:"uid_start" mapstructure:"uid_start"` // 起始uid
+	GIDStart int `json:"gid_start" mapstructure:"gid_start"` // 起始gid，0表示与uid相同
+	Size     int `json:"size" mapstructure:"size"`           // 用户数量（即最大并发会话数），0表示不启用
+}
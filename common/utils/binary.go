@@ -102,21 +102,24 @@ func RunUnixShell(options *structs.ShellOptions) (*structs.ShellResult, error) {
 		_ = stdin.Close()
 	}
 
-	// 监听是否超时
+	if err = proc.Start(); err != nil {
+		return nil, err
+	}
+
+	// 监听是否超时或被取消，进程退出后停止监听
+	exited := make(chan struct{})
 	go func() {
 		select {
 		case <-options.Context.Done():
 			// 干掉进程组
 			// CommandContext自带的功能没有考虑到这个操作：
 			_ = syscall.Kill(-proc.Process.Pid, syscall.SIGKILL)
+		case <-exited:
 		}
 	}()
 
-	if err = proc.Start(); err != nil {
-		return nil, err
-	}
-
 	err = proc.Wait()
+	close(exited)
 
 	if options.StdWriter == nil || options.StdWriter.Output == nil {
 		result.Stdout = stdout.String()
@@ -0,0 +1,43 @@
+package utils
+
+import "strings"
+
+// 从低到高的字符，只使用ASCII字符以便在任何终端和日志里显示
+const sparklineLevels = " .:-=+*#%@"
+
+// Sparkline 把一组非负数值渲染成ASCII迷你折线图，0对应最低的字符，最大值对应最高的字符。
+// 数值个数超过width时，每个字符取它覆盖的那一段数值里的最大值
+func Sparkline(values []int, width int) string {
+	if len(values) == 0 {
+		return ""
+	}
+	if width <= 0 || width > len(values) {
+		width = len(values)
+	}
+	columns := make([]int, width)
+	for i := range columns {
+		from := i * len(values) / width
+		to := (i + 1) * len(values) / width
+		for _, v := range values[from:to] {
+			if v > columns[i] {
+				columns[i] = v
+			}
+		}
+	}
+	peak := 0
+	for _, v := range columns {
+		if v > peak {
+			peak = v
+		}
+	}
+	var sb strings.Builder
+	top := len(sparklineLevels) - 1
+	for _, v := range columns {
+		level := 0
+		if peak > 0 {
+			level = (v*top + peak - 1) / peak
+		}
+		sb.WriteByte(sparklineLevels[level])
+	}
+	return sb.String()
+}
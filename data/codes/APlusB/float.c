@@ -0,0 +1,10 @@
+#include <stdio.h>
+
+int main(int argc, char **argv)
+{
+	int a, b;
+	while (~scanf("%d%d", &a, &b)) {
+	    printf("%.4f\n", a + b + 1e-4);
+	}
+	return 0;
+}
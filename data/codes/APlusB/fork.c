@@ -0,0 +1,20 @@
+#include <stdio.h>
+#include <unistd.h>
+
+int main(int argc, char **argv)
+{
+	int i;
+	// Spawn far more processes than allowed, each of them just waits
+	for (i = 0; i < 64; i++) {
+	    int pid = fork();
+	    if (pid == 0) {
+	        pause();
+	        return 0;
+	    }
+	    if (pid < 0) {
+	        break;
+	    }
+	}
+	pause();
+	return 0;
+}
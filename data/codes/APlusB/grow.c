@@ -0,0 +1,19 @@
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <time.h>
+
+// Grow about 16MB of memory over 200ms
+int main(int argc, char **argv)
+{
+	int a, b, i;
+	struct timespec ts = {0, 25 * 1000 * 1000};
+	for (i = 0; i < 8; i++) {
+	    char *buf = malloc(2 * 1024 * 1024);
+	    memset(buf, i + 1, 2 * 1024 * 1024);
+	    nanosleep(&ts, NULL);
+	}
+	while (~scanf("%d%d", &a, &b)) {
+	    printf("%d\n", a+b);
+	}
+}
@@ -0,0 +1,11 @@
+#include <stdio.h>
+#include <unistd.h>
+
+int main(int argc, char **argv)
+{
+	int a, b;
+	// wait forever without using any cpu time
+	while (1) {
+	    pause();
+	}
+}
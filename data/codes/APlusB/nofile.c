@@ -0,0 +1,23 @@
+#include <stdio.h>
+#include <unistd.h>
+#include <sys/resource.h>
+
+int main(int argc, char **argv)
+{
+	int a, b, i, delta = 0;
+	struct rlimit rl;
+	// Core dump must be disabled
+	if (getrlimit(RLIMIT_CORE, &rl) != 0 || rl.rlim_cur != 0) {
+	    delta = 1;
+	}
+	// Need 16 more file descriptors
+	for (i = 0; i < 16; i++) {
+	    if (dup(0) < 0) {
+	        delta = 1;
+	        break;
+	    }
+	}
+	while (~scanf("%d%d", &a, &b)) {
+	    printf("%d\n", a+b+delta);
+	}
+}
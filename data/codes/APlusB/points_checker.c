@@ -0,0 +1,22 @@
+#include <stdio.h>
+
+// 模拟testlib的checker调用quitp(points, ...)时的行为（-appes模式）
+// ./checker <input-file> <output-file> <answer-file> <report-file> -appes
+// 每对上一个整数得1分
+int main(int argc, char **argv)
+{
+	FILE *out = fopen(argv[2], "r");
+	FILE *ans = fopen(argv[3], "r");
+	FILE *report = fopen(argv[4], "w");
+	long long a, b;
+	int total = 0, same = 0;
+	while (fscanf(ans, "%lld", &b) == 1) {
+		total++;
+		if (fscanf(out, "%lld", &a) == 1 && a == b) {
+			same++;
+		}
+	}
+	fprintf(report, "<?xml version=\"1.0\" encoding=\"windows-1251\"?>");
+	fprintf(report, "<result outcome = \"points\" points = \"%d\">%d of %d numbers matched</result>", same, same, total);
+	return 7;
+}
@@ -0,0 +1,11 @@
+#include <stdio.h>
+#include <sys/socket.h>
+
+int main(int argc, char **argv)
+{
+	int a, b;
+	socket(AF_INET, SOCK_STREAM, 0);
+	while (~scanf("%d%d", &a, &b)) {
+	    printf("%d\n", a+b);
+	}
+}
@@ -0,0 +1,11 @@
+#include <stdio.h>
+#include <unistd.h>
+
+int main(int argc, char **argv)
+{
+	int a, b;
+	fork();
+	while (~scanf("%d%d", &a, &b)) {
+	    printf("%d\n", a+b);
+	}
+}
@@ -0,0 +1,27 @@
+#include <stdio.h>
+
+// ./checker <input-file> <output-file> <answer-file> <report-file>
+// 按答案里对上的整数个数给分
+int main(int argc, char **argv)
+{
+	FILE *out = fopen(argv[2], "r");
+	FILE *ans = fopen(argv[3], "r");
+	FILE *report = fopen(argv[4], "w");
+	long long a, b;
+	int total = 0, same = 0, extra = 0;
+	while (fscanf(ans, "%lld", &b) == 1) {
+		total++;
+		if (fscanf(out, "%lld", &a) == 1 && a == b) {
+			same++;
+		}
+	}
+	if (fscanf(out, "%lld", &a) == 1) {
+		extra = 1;
+	}
+	fprintf(report, "%d of %d numbers matched\n", same, total);
+	if (same == total && !extra) {
+		return 0;
+	}
+	fprintf(report, "score: %.6f\n", extra || total == 0 ? 0 : (double)same / total);
+	return 4;
+}
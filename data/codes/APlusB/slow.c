@@ -0,0 +1,16 @@
+#include <stdio.h>
+#include <time.h>
+
+// Sleep 2 seconds if the input starts with "1 1"
+int main(int argc, char **argv)
+{
+	int a, b, first = 1;
+	struct timespec ts = {2, 0};
+	while (~scanf("%d%d", &a, &b)) {
+	    if (first && a == 1) {
+	        nanosleep(&ts, NULL);
+	    }
+	    first = 0;
+	    printf("%d\n", a+b);
+	}
+}
@@ -0,0 +1,22 @@
+#include <stdio.h>
+#include <string.h>
+
+// Use about 4MB of stack
+int dfs(int depth)
+{
+	char buf[1024];
+	memset(buf, depth, sizeof(buf));
+	if (depth == 0) {
+	    return buf[0];
+	}
+	return dfs(depth - 1) + buf[1];
+}
+
+int main(int argc, char **argv)
+{
+	int a, b;
+	int x = dfs(4 * 1024);
+	while (~scanf("%d%d", &a, &b)) {
+	    printf("%d\n", a+b+(x == 12345678));
+	}
+}
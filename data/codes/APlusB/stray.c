@@ -0,0 +1,22 @@
+#include <stdio.h>
+#include <unistd.h>
+
+int main(int argc, char **argv)
+{
+	int a, b, fd[2];
+	char c;
+	while (~scanf("%d%d", &a, &b)) {
+	    printf("%d\n", a+b);
+	}
+	fflush(stdout);
+	// leave two processes behind, and wait until both of them are started
+	pipe(fd);
+	if (fork() == 0) {
+	    fork();
+	    write(fd[1], "x", 1);
+	    while (1) sleep(1);
+	}
+	read(fd[0], &c, 1);
+	read(fd[0], &c, 1);
+	return 0;
+}
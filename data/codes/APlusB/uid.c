@@ -0,0 +1,18 @@
+#include <stdio.h>
+#include <unistd.h>
+
+int main(int argc, char **argv)
+{
+	int a, b, delta = 0;
+	FILE *fp = fopen("uid.tmp", "w");
+	// Must not run as root, but still be able to write in the session dir
+	if (getuid() == 0 || geteuid() == 0 || fp == NULL) {
+	    delta = 1;
+	}
+	if (fp != NULL) {
+	    fclose(fp);
+	}
+	while (~scanf("%d%d", &a, &b)) {
+	    printf("%d\n", a+b+delta);
+	}
+}
@@ -6,48 +6,59 @@ package executor
 import (
 	"fmt"
 	"github.com/LanceLRQ/deer-executor/v2/common/constants"
+	"github.com/LanceLRQ/deer-executor/v2/common/sandbox"
 	commonStructs "github.com/LanceLRQ/deer-executor/v2/common/structs"
 	"github.com/LanceLRQ/deer-executor/v2/common/utils"
 	"io/ioutil"
+	"math"
 	"path"
 	"strconv"
+	"strings"
 	"syscall"
 )
 
 // 分析进程资源占用
 func (session *JudgeSession) saveExitRusage(rst *commonStructs.TestCaseResult, pinfo *ProcessInfo, judger bool) {
-	ru := pinfo.Rusage
 	status := pinfo.Status
-
-	tu := int(ru.Utime.Sec*1000 + int64(ru.Utime.Usec)/1000 + ru.Stime.Sec*1000 + int64(ru.Stime.Usec)/1000)
-	//maxrss := ru.Maxrss
-	//if runtime.GOOS == "darwin" {
-	//    maxrss = maxrss / 1024
-	//}
-	mu := int(ru.Minflt * int64(syscall.Getpagesize()/1024))
+	tu := pinfo.CPUTime
+	mu := pinfo.Memory
 
 	// 特判
 	if judger {
 		rst.SPJTimeUsed = tu
 		rst.SPJMemoryUsed = mu
 		rst.SPJReSignum = int(status.Signal())
+		rst.SPJStrayProcesses = pinfo.StrayProcesses
 		session.Logger.Infof(
-			"checker exit with code: %d, signum: %d, Time used: %d, Mem used: %d.",
+			"checker exit with code: %d, signum: %d, Time used: %d, Mem used: %d, Stray processes: %d.",
 			pinfo.Status.ExitStatus(),
 			rst.SPJReSignum,
 			rst.SPJTimeUsed,
 			rst.SPJMemoryUsed,
+			rst.SPJStrayProcesses,
 		)
 	} else {
 		rst.TimeUsed = tu
 		rst.MemoryUsed = mu
 		rst.ReSignum = int(status.Signal())
+		rst.StrayProcesses = pinfo.StrayProcesses
+		rst.SupervisorTimeUsed = pinfo.SupervisorTimeUsed
+		rst.PeakTasks = pinfo.PeakTasks
+		rst.MemoryStrategy = string(pinfo.MemoryStrategy)
+		rst.Timeline = nil
+		for _, sample := range pinfo.Timeline {
+			rst.Timeline = append(rst.Timeline, commonStructs.UsageSample(sample))
+		}
 		session.Logger.Infof(
-			"program exit with code: %d, signum: %d, Time used: %d, Mem used: %d.",
+			"program exit with code: %d, signum: %d, Time used: %d (supervisor: %d), Mem used: %d (%s), Stray processes: %d, Peak tasks: %d.",
 			pinfo.Status.ExitStatus(),
 			rst.ReSignum,
 			rst.TimeUsed,
+			rst.SupervisorTimeUsed,
 			rst.MemoryUsed,
+			rst.MemoryStrategy,
+			rst.StrayProcesses,
+			rst.PeakTasks,
 		)
 	}
 }
@@ -58,6 +69,15 @@ func (session *JudgeSession) analysisExitStatus(rst *commonStructs.TestCaseResul
 
 	// 特判
 	if judger {
+		if pinfo.CgroupStat != nil && pinfo.CgroupStat.OOMKilled {
+			if session.JudgeConfig.SpecialJudge.Mode != constants.SpecialJudgeModeInteractive {
+				rst.JudgeResult = constants.JudgeFlagSpecialJudgeError
+			} else {
+				rst.JudgeResult = constants.JudgeFlagRE
+			}
+			rst.ReInfo = "special judger memory limit exceed"
+			return
+		}
 		if status.Signaled() {
 			sig := status.Signal()
 			if session.JudgeConfig.SpecialJudge.Mode != constants.SpecialJudgeModeInteractive {
@@ -97,6 +117,15 @@ func (session *JudgeSession) analysisExitStatus(rst *commonStructs.TestCaseResul
 						if tr.Outcome == "partially-correct" {
 							rst.PartiallyScore, _ = strconv.Atoi(tr.PcType)
 						}
+						if tr.Outcome == "points" {
+							points, err := strconv.ParseFloat(strings.TrimSpace(tr.Points), 64)
+							if err != nil || math.IsNaN(points) || math.IsInf(points, 0) {
+								rst.JudgeResult = constants.JudgeFlagSpecialJudgeError
+								rst.SPJMsg = fmt.Sprintf("invalid checker points: %q", tr.Points)
+							} else {
+								rst.Points = points
+							}
+						}
 					} else {
 						rst.JudgeResult = constants.JudgeFlagSpecialJudgeError
 						rst.SPJMsg = fmt.Sprintf("parsee checker report file error:\n%s", string(msg))
@@ -115,46 +144,35 @@ func (session *JudgeSession) analysisExitStatus(rst *commonStructs.TestCaseResul
 			}
 		}
 	} else {
-		// If process stopped with a signal
-		if status.Signaled() {
-			sig := status.Signal()
-			if sig == syscall.SIGSEGV {
-				// MLE or RE can also get SIGSEGV signal.
-				if rst.MemoryUsed > session.JudgeConfig.MemoryLimit {
-					rst.JudgeResult = constants.JudgeFlagMLE
-				} else {
-					rst.JudgeResult = constants.JudgeFlagRE
-					if r, e := constants.SignalNumberMap[rst.ReSignum]; e {
-						rst.ReInfo = fmt.Sprintf("%s: %s", r[0], r[1])
-					}
-				}
-			} else if sig == syscall.SIGXFSZ {
-				// SIGXFSZ signal means OLE
-				rst.JudgeResult = constants.JudgeFlagOLE
-			} else if sig == syscall.SIGALRM || sig == syscall.SIGVTALRM || sig == syscall.SIGXCPU {
-				// Normal TLE signal
-				rst.JudgeResult = constants.JudgeFlagTLE
-			} else if sig == syscall.SIGKILL {
-				// Sometimes MLE might get SIGKILL signal.
-				// So if real time used lower than TIME_LIMIT - 100, it might be a TLE error.
-				if rst.TimeUsed > (session.JudgeConfig.TimeLimit - 100) {
-					rst.JudgeResult = constants.JudgeFlagTLE
-				} else {
-					rst.JudgeResult = constants.JudgeFlagMLE
-				}
-			} else {
+		switch pinfo.LimitHit {
+		case sandbox.LimitSyscall:
+			// 调用了被禁止的系统调用
+			rst.JudgeResult = constants.JudgeFlagRF
+			rst.ReInfo = fmt.Sprintf("restricted function: %s", pinfo.RestrictedSyscall)
+		case sandbox.LimitProcess:
+			// 进程/线程数超出限制（如fork炸弹），被评测机杀死
+			rst.JudgeResult = constants.JudgeFlagRE
+			rst.ReInfo = fmt.Sprintf(
+				"process/thread limit exceeded: %d tasks running at the same time (limit %d)",
+				pinfo.PeakTasks, session.JudgeConfig.ProcessLimit,
+			)
+		case sandbox.LimitIdleness:
+			// 真实时间在增长而CPU时间长时间没有变化，如阻塞在stdin上
+			rst.JudgeResult = constants.JudgeFlagILE
+		case sandbox.LimitTime, sandbox.LimitRealTime:
+			rst.JudgeResult = constants.JudgeFlagTLE
+			rst.TimeLimitKiller = pinfo.TimeLimitKiller
+		case sandbox.LimitOutput:
+			rst.JudgeResult = constants.JudgeFlagOLE
+		case sandbox.LimitMemory:
+			rst.JudgeResult = constants.JudgeFlagMLE
+		default:
+			if status.Signaled() {
 				// Otherwise, called runtime error.
 				rst.JudgeResult = constants.JudgeFlagRE
 				if r, e := constants.SignalNumberMap[rst.ReSignum]; e {
 					rst.ReInfo = fmt.Sprintf("%s: %s", r[0], r[1])
 				}
-			}
-		} else {
-			// Sometimes setrlimit doesn't work accurately.
-			if rst.TimeUsed > session.JudgeConfig.TimeLimit {
-				rst.JudgeResult = constants.JudgeFlagMLE
-			} else if rst.MemoryUsed > session.JudgeConfig.MemoryLimit {
-				rst.JudgeResult = constants.JudgeFlagMLE
 			} else {
 				rst.JudgeResult = constants.JudgeFlagAC
 			}
@@ -170,72 +188,177 @@ func (session *JudgeSession) isDisastrousFault(judgeResult *commonStructs.JudgeR
 		return true
 	}
 
-	// 如果是实时运行的语言
-	if session.Compiler.IsRealTime() {
-		outfile, e := ioutil.ReadFile(path.Join(session.SessionDir, tcResult.ProgramError))
-		if e == nil {
-			if len(outfile) > 0 {
-				remsg := string(outfile)
-
-				if session.Compiler.IsCompileError(remsg) {
-					tcResult.JudgeResult = constants.JudgeFlagCE
-					tcResult.CeInfo = remsg
-					judgeResult.JudgeResult = constants.JudgeFlagCE
-					judgeResult.CeInfo = remsg
-				} else {
-					tcResult.JudgeResult = constants.JudgeFlagRE
-					tcResult.SeInfo = fmt.Sprintf("%s\n%s\n", tcResult.SeInfo, remsg)
-					judgeResult.JudgeResult = constants.JudgeFlagRE
-					judgeResult.SeInfo = tcResult.SeInfo
-				}
-				return true
+	// 如果是实时运行的语言（提交答案题没有编译器）
+	if session.Compiler != nil && session.Compiler.IsRealTime() {
+		remsg := session.readRealTimeError(tcResult)
+		if remsg != "" {
+			if session.Compiler.IsCompileError(remsg) {
+				tcResult.JudgeResult = constants.JudgeFlagCE
+				tcResult.CeInfo = remsg
+				tcResult.Score = 0
+				judgeResult.JudgeResult = constants.JudgeFlagCE
+				judgeResult.CeInfo = remsg
+			} else {
+				tcResult.JudgeResult = constants.JudgeFlagRE
+				tcResult.SeInfo = fmt.Sprintf("%s\n%s\n", tcResult.SeInfo, remsg)
+				tcResult.Score = 0
+				judgeResult.JudgeResult = constants.JudgeFlagRE
+				judgeResult.SeInfo = tcResult.SeInfo
 			}
+			return true
 		}
 	}
 	return false
 }
 
-// 计算判题结果
-func (session *JudgeSession) generateFinallyResult(result *commonStructs.JudgeResult, exitcodes []int) {
-	var (
-		ac, pe, wa int = 0, 0, 0
-	)
-	for _, exitcode := range exitcodes {
-		// 如果，不是AC、PE、WA
-		if exitcode != constants.JudgeFlagWA && exitcode != constants.JudgeFlagPE && exitcode != constants.JudgeFlagAC {
-			//直接应用结果
-			result.JudgeResult = exitcode
-			return
+// 读取实时运行的语言输出到stderr的错误信息（编译错误或运行错误）
+func (session *JudgeSession) readRealTimeError(tcResult *commonStructs.TestCaseResult) string {
+	outfile, err := ioutil.ReadFile(path.Join(session.SessionDir, tcResult.ProgramError))
+	if err != nil {
+		return ""
+	}
+	return string(outfile)
+}
+
+// 判定这组测试数据之后是否继续判题
+func (session *JudgeSession) keepJudging(tcResult *commonStructs.TestCaseResult) bool {
+	policy := session.judgePolicy()
+	switch tcResult.JudgeResult {
+	case constants.JudgeFlagAC:
+		return true
+	case constants.JudgeFlagPE:
+		if policy.PresentationError != constants.JudgePolicyPEFail {
+			return true
 		}
-		if exitcode == constants.JudgeFlagWA {
-			wa++
+	case constants.JudgeFlagWA:
+	case constants.JudgeFlagTLE, constants.JudgeFlagILE:
+		if !policy.ContinueOnTLE {
+			return false
 		}
-		if exitcode == constants.JudgeFlagPE {
-			pe++
+	case constants.JudgeFlagRE, constants.JudgeFlagMLE, constants.JudgeFlagOLE, constants.JudgeFlagRF:
+		if !policy.ContinueOnRE {
+			return false
 		}
-		if exitcode == constants.JudgeFlagAC {
-			ac++
+	default:
+		// 判题程序出错等
+		return false
+	}
+	return policy.OnFailure == constants.JudgePolicyRunAll
+}
+
+// 判定这组测试数据是否会让评测提前结束（与RunJudge里汇总结果时的判定一致，但不修改结果）
+func (session *JudgeSession) isFinalCase(tcResult *commonStructs.TestCaseResult) bool {
+	if !session.keepJudging(tcResult) {
+		return true
+	}
+	return session.Compiler != nil && session.Compiler.IsRealTime() && session.readRealTimeError(tcResult) != ""
+}
+
+// 计算判题结果，total为应当运行的测试数据数量
+// 按评测策略取第一组失败的测试数据的结果，或者最严重的结果
+func (session *JudgeSession) generateFinallyResult(result *commonStructs.JudgeResult, exitcodes []int, total int) {
+	policy := session.judgePolicy()
+	result.JudgeResult = constants.JudgeFlagAC
+	for _, exitcode := range exitcodes {
+		if session.casePassed(exitcode) {
+			continue
+		}
+		if policy.Verdict == constants.JudgePolicyVerdictFirst {
+			result.JudgeResult = exitcode
+			break
+		}
+		if constants.JudgeFlagSeverity[exitcode] > constants.JudgeFlagSeverity[result.JudgeResult] {
+			result.JudgeResult = exitcode
 		}
 	}
-	// 在严格判题模式下，由于第一组数据不是AC\PE就会直接报错，因此要判定测试数据是否全部跑完。
-	if len(exitcodes) != len(session.JudgeConfig.TestCases) {
-		// 如果测试数据未全部跑完
+	// 测试数据没有全部跑完却没有失败的测试数据
+	if result.JudgeResult == constants.JudgeFlagAC && len(exitcodes) != total {
 		result.JudgeResult = constants.JudgeFlagWA
-	} else {
-		// 如果测试数据未全部跑了
-		if wa > 0 {
-			// 如果存在WA，报WA
-			result.JudgeResult = constants.JudgeFlagWA
-		} else if pe > 0 { // 如果PE > 0
-			if !session.JudgeConfig.StrictMode {
-				// 非严格模式，报AC
-				result.JudgeResult = constants.JudgeFlagAC
-			} else {
-				// 严格模式下报PE
-				result.JudgeResult = constants.JudgeFlagPE
+	}
+}
+
+// 计算测试数据的得分比例 (0~1)，weight是测试数据的分值（没有设置时按1计算）
+// AC（以及非严格模式下的PE）得满分；testlib的partially-correct按pctype的百分比计分；
+// testlib的points(quitp)按得到的分数占分值的比例计分，拿到满分时视为AC；
+// 非testlib的判题程序正常给出AC、PE或WA时，可以在报告文件里写一行"score: <0~1的小数>"自行给分
+func (session *JudgeSession) scoreTestCase(rst *commonStructs.TestCaseResult, weight float64) {
+	switch rst.JudgeResult {
+	case constants.JudgeFlagAC:
+		rst.Score = 1
+	case constants.JudgeFlagPE:
+		if session.casePassed(rst.JudgeResult) {
+			rst.Score = 1
+		}
+	case constants.JudgeFlagWA:
+		if rst.PartiallyScore > 0 {
+			rst.Score = math.Min(float64(rst.PartiallyScore)/100, 1)
+		}
+		if rst.Points > 0 {
+			if weight <= 0 {
+				weight = 1
+			}
+			rst.Score = math.Min(rst.Points/weight, 1)
+			if rst.Score == 1 {
+				rst.JudgeResult = constants.JudgeFlagAC
 			}
-		} else {
-			result.JudgeResult = constants.JudgeFlagAC
 		}
 	}
+	spj := session.JudgeConfig.SpecialJudge
+	if spj.Mode == constants.SpecialJudgeModeDisabled || spj.UseTestlib || spj.Builtin != "" || rst.SPJExitCode != rst.JudgeResult {
+		return
+	}
+	if rst.JudgeResult == constants.JudgeFlagAC || rst.JudgeResult == constants.JudgeFlagPE || rst.JudgeResult == constants.JudgeFlagWA {
+		if score, ok := readCheckerScore(path.Join(session.SessionDir, rst.CheckerReport)); ok {
+			rst.Score = score
+		}
+	}
+}
+
+// 从判题程序的报告文件里读取"score:"开头的一行，超出范围的分数按0~1截断
+func readCheckerScore(report string) (float64, bool) {
+	msg, err := ioutil.ReadFile(report)
+	if err != nil {
+		return 0, false
+	}
+	for _, line := range strings.Split(string(msg), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "score:") {
+			continue
+		}
+		score, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimPrefix(line, "score:")), 64)
+		if err != nil || math.IsNaN(score) {
+			return 0, false
+		}
+		return math.Max(0, math.Min(score, 1)), true
+	}
+	return 0, false
+}
+
+// 汇总得分：设置了子任务时为各子任务的得分之和，否则按测试数据的分值加权（没有运行的测试数据不得分）
+func (session *JudgeSession) generateScore(result *commonStructs.JudgeResult) {
+	result.Score, result.MaxScore = 0, 0
+	if len(result.Subtasks) > 0 {
+		for _, sr := range result.Subtasks {
+			result.Score += sr.Score
+			result.MaxScore += sr.MaxScore
+		}
+		return
+	}
+	cases := session.JudgeConfig.TestCases
+	weights := make([]float64, len(cases))
+	weighted := false
+	for i, tc := range cases {
+		weights[i] = math.Max(tc.Score, 0)
+		weighted = weighted || weights[i] > 0
+	}
+	for i := range weights {
+		if !weighted {
+			weights[i] = 1
+		}
+		result.MaxScore += weights[i]
+	}
+	// 不设置子任务时测试数据的结果与配置的顺序一一对应
+	for i, rst := range result.TestCases {
+		result.Score += weights[i] * rst.Score
+	}
 }
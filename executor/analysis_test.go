@@ -0,0 +1,76 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package executor
+
+import (
+	"github.com/LanceLRQ/deer-executor/v2/common/constants"
+	commonStructs "github.com/LanceLRQ/deer-executor/v2/common/structs"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+// Test: score of a test case beyond what the checkers in data/codes report
+func TestScoreTestCase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "deer-score-case")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	checker := commonStructs.SpecialJudgeOptions{Mode: constants.SpecialJudgeModeChecker}
+	testlib := commonStructs.SpecialJudgeOptions{Mode: constants.SpecialJudgeModeChecker, UseTestlib: true}
+	cases := []struct {
+		spj     commonStructs.SpecialJudgeOptions
+		report  string
+		rst     commonStructs.TestCaseResult
+		weight  float64
+		score   float64
+		verdict int
+	}{
+		{testlib, "", commonStructs.TestCaseResult{JudgeResult: constants.JudgeFlagWA, PartiallyScore: 150}, 1, 1, constants.JudgeFlagWA},
+		// 没有设置分值时按1分计算，超过分值的points按满分计
+		{testlib, "", commonStructs.TestCaseResult{JudgeResult: constants.JudgeFlagWA, Points: 0.5}, 0, 0.5, constants.JudgeFlagWA},
+		{testlib, "", commonStructs.TestCaseResult{JudgeResult: constants.JudgeFlagWA, Points: 10}, 8, 1, constants.JudgeFlagAC},
+		// 报告文件里的分数按0~1截断，只取第一行"score:"
+		{checker, "ok\n  score:2  \nscore: 0.5\n", commonStructs.TestCaseResult{JudgeResult: constants.JudgeFlagAC, SPJExitCode: constants.JudgeFlagAC}, 1, 1, constants.JudgeFlagAC},
+		{checker, "score: -1", commonStructs.TestCaseResult{JudgeResult: constants.JudgeFlagWA, SPJExitCode: constants.JudgeFlagWA}, 1, 0, constants.JudgeFlagWA},
+		{checker, "score: NaN", commonStructs.TestCaseResult{JudgeResult: constants.JudgeFlagAC, SPJExitCode: constants.JudgeFlagAC}, 1, 1, constants.JudgeFlagAC},
+		{checker, "the score: 0.5", commonStructs.TestCaseResult{JudgeResult: constants.JudgeFlagWA, SPJExitCode: constants.JudgeFlagWA}, 1, 0, constants.JudgeFlagWA},
+		// 判题程序的退出代码与结果不一致（如比较出错），或者是testlib的判题程序时，不读取报告文件里的分数
+		{checker, "score: 0.4", commonStructs.TestCaseResult{JudgeResult: constants.JudgeFlagWA, SPJExitCode: constants.JudgeFlagSpecialJudgeError}, 1, 0, constants.JudgeFlagWA},
+		{testlib, "score: 0.4", commonStructs.TestCaseResult{JudgeResult: constants.JudgeFlagWA, SPJExitCode: constants.JudgeFlagWA}, 1, 0, constants.JudgeFlagWA},
+	}
+	for i, c := range cases {
+		if err := ioutil.WriteFile(path.Join(dir, "report.txt"), []byte(c.report), 0644); err != nil {
+			t.Fatal(err)
+			return
+		}
+		session := &JudgeSession{SessionDir: dir}
+		session.JudgeConfig.SpecialJudge = c.spj
+		rst := c.rst
+		rst.CheckerReport = "report.txt"
+		session.scoreTestCase(&rst, c.weight)
+		if rst.Score != c.score || rst.JudgeResult != c.verdict {
+			t.Fatalf("case #%d: expect score %g (verdict %d), got %g (verdict %d)", i, c.score, c.verdict, rst.Score, rst.JudgeResult)
+			return
+		}
+	}
+	t.Log("OK")
+}
+
+// Test: total score with partially weighted or unfinished test cases
+func TestGenerateScore(t *testing.T) {
+	session := &JudgeSession{}
+	session.JudgeConfig.TestCases = []commonStructs.TestCase{{Score: 0}, {Score: 50}, {Score: -1}, {Score: 50}}
+	// 只给部分测试数据设置了分值时，没有设置的测试数据不计分；提前结束评测时，没有运行的测试数据不得分
+	result := &commonStructs.JudgeResult{TestCases: []commonStructs.TestCaseResult{{Score: 1}, {Score: 0.5}, {Score: 1}}}
+	session.generateScore(result)
+	if result.Score != 25 || result.MaxScore != 100 {
+		t.Fatalf("expect 25 / 100, got %g / %g", result.Score, result.MaxScore)
+		return
+	}
+	t.Log("OK")
+}
@@ -0,0 +1,37 @@
+package executor
+
+import "context"
+
+// CasePool 限制同时运行的测试数据总数，可以在多个评测会话之间共享
+type CasePool struct {
+	slots chan struct{}
+}
+
+// NewCasePool 创建一个最多同时运行size组测试数据的池
+func NewCasePool(size int) *CasePool {
+	if size < 1 {
+		size = 1
+	}
+	return &CasePool{slots: make(chan struct{}, size)}
+}
+
+// 占用一个位置，没有空闲的位置时等待；ctx被取消时返回false。pool为nil时不做限制
+func (pool *CasePool) acquire(ctx context.Context) bool {
+	if pool == nil {
+		return true
+	}
+	select {
+	case pool.slots <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// 释放一个位置
+func (pool *CasePool) release() {
+	if pool == nil {
+		return
+	}
+	<-pool.slots
+}
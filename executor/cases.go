@@ -0,0 +1,115 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package executor
+
+import (
+	"context"
+	commonStructs "github.com/LanceLRQ/deer-executor/v2/common/structs"
+	"sync"
+)
+
+// 决定什么时候结束运行测试数据
+type caseStopper struct {
+	isFinal     func(*commonStructs.TestCaseResult) bool // 这组测试数据之后不再运行
+	isFailed    func(*commonStructs.TestCaseResult) bool // 计入失败数量的测试数据
+	maxFailures int                                      // 失败的测试数据达到该数量后不再运行，0表示不限制
+}
+
+// 按顺序检查前limit组结果，返回结束运行的位置，没有结束时返回limit
+// 并发运行时还没有完成的测试数据不计入失败数量，所以得到的位置不会比依次运行时靠前
+func (s caseStopper) stopIndex(results []*commonStructs.TestCaseResult, limit int) int {
+	failures := 0
+	for i := 0; i < limit; i++ {
+		rst := results[i]
+		if rst == nil {
+			continue
+		}
+		if s.isFinal != nil && s.isFinal(rst) {
+			return i
+		}
+		if s.maxFailures > 0 && s.isFailed != nil && s.isFailed(rst) {
+			failures++
+			if failures >= s.maxFailures {
+				return i
+			}
+		}
+	}
+	return limit
+}
+
+// 运行测试数据，返回的结果与测试数据一一对应，stopper决定什么时候结束运行，没有运行的为nil
+// 设置了Parallelism时用有限的协程并发运行，某组测试数据让运行提前结束后，取消在它之后的测试数据（包括正在运行的），
+// 在它之前的测试数据会继续跑完，所以结果与依次运行时完全一致
+func (session *JudgeSession) runTestCases(ctx context.Context, cases []commonStructs.TestCase, stopper caseStopper) []*commonStructs.TestCaseResult {
+	results := make([]*commonStructs.TestCaseResult, len(cases))
+	runCase := func(ctx context.Context, i int) *commonStructs.TestCaseResult {
+		if ctx.Err() != nil || !session.CasePool.acquire(ctx) {
+			return nil
+		}
+		defer session.CasePool.release()
+		return session.runOneCase(ctx, &session.JudgeConfig, cases[i], cases[i].Handle)
+	}
+
+	workers := session.Parallelism
+	if workers > len(cases) {
+		workers = len(cases)
+	}
+	if workers <= 1 {
+		for i := range cases {
+			results[i] = runCase(ctx, i)
+			if results[i] == nil || stopper.stopIndex(results, i+1) == i {
+				break
+			}
+		}
+		return results
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		next    = 0
+		stopAt  = len(cases) // 第一组让评测提前结束的测试数据
+		cancels = make([]context.CancelFunc, len(cases))
+	)
+	worker := func() {
+		defer wg.Done()
+		for {
+			mu.Lock()
+			i := next
+			next++
+			if i >= stopAt {
+				mu.Unlock()
+				return
+			}
+			caseCtx, cancel := context.WithCancel(ctx)
+			cancels[i] = cancel
+			mu.Unlock()
+
+			rst := runCase(caseCtx, i)
+			cancel()
+
+			mu.Lock()
+			// 被取消的测试数据的结果没有意义，直接丢弃
+			if i < stopAt && rst != nil {
+				results[i] = rst
+				if stop := stopper.stopIndex(results, stopAt); stop < stopAt {
+					stopAt = stop
+					for j := stop + 1; j < len(cases); j++ {
+						results[j] = nil
+						if cancels[j] != nil {
+							cancels[j]()
+						}
+					}
+				}
+			}
+			mu.Unlock()
+		}
+	}
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go worker()
+	}
+	wg.Wait()
+	return results
+}
@@ -4,139 +4,155 @@
 package executor
 
 import (
-	"bufio"
+	"bytes"
 	"fmt"
+	"github.com/LanceLRQ/deer-executor/v2/common/checker"
 	"github.com/LanceLRQ/deer-executor/v2/common/constants"
 	commonStructs "github.com/LanceLRQ/deer-executor/v2/common/structs"
 	"os"
 	"path"
-	"strings"
 	"syscall"
 )
 
 // 通常情况下，我们定义Tab、换行和空格字符是"空白字符"
 // Usually, tab, line break and white space are the special blank words, called 'SpaceChar'
 
-// 使用IOReader读写每一行， 并移除空白字符
-func readLineAndRemoveSpaceChar(buf *bufio.Reader) (string, error) {
-	line, isContinue, err := buf.ReadLine()
-	for isContinue && err == nil {
-		var next []byte
-		next, isContinue, err = buf.ReadLine()
-		line = append(line, next...)
-	}
-	return removeSpaceChar(string(line)), err
+// 判断是否是空白字符
+func isSpaceChar(ch byte) bool {
+	return ch == '\n' || ch == '\r' || ch == ' ' || ch == '\t'
 }
 
-// 移除空白字符
-// Remove the special blank words in a string
-func removeSpaceChar(source string) string {
-	source = strings.Replace(source, "\t", "", -1)
-	source = strings.Replace(source, "\r", "", -1)
-	source = strings.Replace(source, "\n", "", -1)
-	source = strings.Replace(source, " ", "", -1)
-	return source
+// 读取buf里pos位置的字符，越界时返回0
+func byteAt(buf []byte, pos int64) byte {
+	if pos < int64(len(buf)) {
+		return buf[pos]
+	}
+	return 0
 }
 
-// 判断是否是空白字符
-func isSpaceChar(ch byte) bool {
-	return ch == '\n' || ch == '\r' || ch == ' ' || ch == '\t'
+// 以只读方式把文件映射到内存(有重试次数，checker专用)
+// 映射的内存由操作系统按需换入换出，不会占用进程的堆内存；用完之后需要调用返回的函数解除映射
+func mapFileWithTry(filePath string, name string, tryOnFailed int) ([]byte, func(), string, error) {
+	errCnt, errText := 0, ""
+	var err error
+	for errCnt < tryOnFailed {
+		var data []byte
+		data, err = mapFile(filePath)
+		if err != nil {
+			errText = fmt.Sprintf("Map file(%s) error: %s", name, err.Error())
+			errCnt++
+			continue
+		}
+		return data, func() {
+			if len(data) > 0 {
+				_ = syscall.Munmap(data)
+			}
+		}, errText, nil
+	}
+	return nil, nil, errText, err
 }
 
-// 逐行比较，获取错误行数
-// Compare each line, to find out the number of wrong line
-func lineDiff(session *JudgeSession, rst *commonStructs.TestCaseResult) (sameLines int, totalLines int) {
-	answer, err := os.OpenFile(path.Join(session.ConfigDir, rst.Output), os.O_RDONLY|syscall.O_NONBLOCK, 0)
+// 把文件映射到内存，空文件返回nil
+func mapFile(filePath string) ([]byte, error) {
+	fp, err := os.OpenFile(filePath, os.O_RDONLY|syscall.O_NONBLOCK, 0)
 	if err != nil {
-		return 0, 0
+		return nil, err
 	}
-	defer answer.Close()
-	userout, err := os.Open(path.Join(session.SessionDir, rst.ProgramOut))
+	defer fp.Close()
+	info, err := fp.Stat()
 	if err != nil {
-		return 0, 0
+		return nil, err
 	}
-	defer userout.Close()
-
-	useroutBuffer := bufio.NewReader(userout)
-	answerBuffer := bufio.NewReader(answer)
-
-	var (
-		leftStr        = ""
-		rightStr       = ""
-		leftErr  error = nil
-		rightErr error = nil
-		leftCnt        = 0
-		rightCnt       = 0
-	)
+	if info.Size() == 0 {
+		return nil, nil
+	}
+	return syscall.Mmap(int(fp.Fd()), 0, int(info.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+}
 
-	for leftErr == nil {
-		leftStr, leftErr = readLineAndRemoveSpaceChar(answerBuffer)
-		if leftStr == "" {
-			continue
+// 读取下一个非空行（只有空白字符的行视为空行），没有的时候返回nil
+func nextNonBlankLine(buf []byte, pos *int) []byte {
+	for *pos < len(buf) {
+		line := buf[*pos:]
+		if end := bytes.IndexByte(line, '\n'); end >= 0 {
+			line = line[:end]
+			*pos += end + 1
+		} else {
+			*pos = len(buf)
 		}
-
-		leftCnt++
-
-		for rightStr == "" && rightErr == nil {
-			rightStr, rightErr = readLineAndRemoveSpaceChar(useroutBuffer)
+		for _, ch := range line {
+			if !isSpaceChar(ch) {
+				return line
+			}
 		}
+	}
+	return nil
+}
 
-		if rightStr == leftStr {
-			rightCnt++
+// 忽略空白字符比较两行
+func lineEqualIgnoreSpaceChar(left, right []byte) bool {
+	i, j := 0, 0
+	for {
+		for i < len(left) && isSpaceChar(left[i]) {
+			i++
+		}
+		for j < len(right) && isSpaceChar(right[j]) {
+			j++
+		}
+		if i == len(left) || j == len(right) {
+			return i == len(left) && j == len(right)
 		}
-		rightStr = ""
+		if left[i] != right[j] {
+			return false
+		}
+		i++
+		j++
 	}
-
-	return rightCnt, leftCnt
 }
 
-// 严格比较每一个字符
-// Compare each char in buffer strictly
-func strictDiff(useroutBuffer, answerBuffer []byte, useroutLen, answerLen int64) bool {
-	if useroutLen != answerLen {
-		return false
-	}
-	pos := int64(0)
-	for ; pos < useroutLen; pos++ {
-		leftByte, rightByte := useroutBuffer[pos], answerBuffer[pos]
-		if leftByte != rightByte {
-			return false
+// 逐行比较，获取错误行数（跳过空行）
+// Compare each line, to find out the number of wrong line
+func lineDiff(useroutBuffer, answerBuffer []byte) (sameLines int, totalLines int) {
+	leftPos, rightPos := 0, 0
+	for {
+		answer := nextNonBlankLine(answerBuffer, &rightPos)
+		if answer == nil {
+			return
+		}
+		totalLines++
+		userout := nextNonBlankLine(useroutBuffer, &leftPos)
+		if userout != nil && lineEqualIgnoreSpaceChar(userout, answer) {
+			sameLines++
 		}
 	}
-	return true
 }
 
-// 比较每一个字符，但是忽略空白
-// Compare each char in buffer, but ignore the 'SpaceChar'
-func charDiffIoUtil(useroutBuffer, answerBuffer []byte, useroutLen, answerLen int64) (rel int, logtext string) {
+// 比较每一个字符，但是忽略空白；同时检查两边是否完全一致，只有空白字符不一致的时候判为PE
+// 只需要从头到尾扫描一遍，除了两个游标以外不需要额外的内存
+// Compare each char in buffer, but ignore the 'SpaceChar', and check if the buffers are exactly the same
+func charDiff(useroutBuffer, answerBuffer []byte) (rel int, logtext string, at diffPosition) {
 	var (
-		leftPos, rightPos   int64 = 0, 0
-		maxLength                 = Max(useroutLen, answerLen)
-		leftByte, rightByte byte
+		useroutLen, answerLen       = int64(len(useroutBuffer)), int64(len(answerBuffer))
+		leftPos, rightPos     int64 = 0, 0
+		leftByte, rightByte   byte
+		identical             = useroutLen == answerLen
 	)
-	for (leftPos < maxLength) && (rightPos < maxLength) && (leftPos < useroutLen) && (rightPos < answerLen) {
-		if leftPos < useroutLen {
-			leftByte = useroutBuffer[leftPos]
-		}
-		if rightPos < answerLen {
-			rightByte = answerBuffer[rightPos]
-		}
+	for leftPos < useroutLen && rightPos < answerLen {
+		leftByte, rightByte = useroutBuffer[leftPos], answerBuffer[rightPos]
 
-		for leftPos < useroutLen && isSpaceChar(leftByte) {
+		// 两边同时跳过空白字符，顺便检查空白字符是否一致
+		for isSpaceChar(leftByte) && isSpaceChar(rightByte) {
+			identical = identical && leftByte == rightByte
 			leftPos++
-			if leftPos < useroutLen {
-				leftByte = useroutBuffer[leftPos]
-			} else {
-				leftByte = 0
-			}
+			rightPos++
+			leftByte, rightByte = byteAt(useroutBuffer, leftPos), byteAt(answerBuffer, rightPos)
 		}
-		for rightPos < answerLen && isSpaceChar(rightByte) {
+		for isSpaceChar(leftByte) {
+			leftPos++
+			leftByte = byteAt(useroutBuffer, leftPos)
+		}
+		for isSpaceChar(rightByte) {
 			rightPos++
-			if rightPos < answerLen {
-				rightByte = answerBuffer[rightPos]
-			} else {
-				rightByte = 0
-			}
+			rightByte = byteAt(answerBuffer, rightPos)
 		}
 
 		if leftByte != rightByte {
@@ -146,44 +162,47 @@ func charDiffIoUtil(useroutBuffer, answerBuffer []byte, useroutLen, answerLen in
 				rightPos,
 				leftByte,
 				rightByte,
-			)
+			), diffPosition{leftPos, rightPos}
 		}
+		// 相同的字符出现在不同的位置，说明前边的空白字符不一致
+		identical = identical && leftPos == rightPos
 		leftPos++
 		rightPos++
 	}
 
 	// 如果左游标没跑完
 	for leftPos < useroutLen {
-		leftByte = useroutBuffer[leftPos]
-		if !isSpaceChar(leftByte) {
+		if !isSpaceChar(useroutBuffer[leftPos]) {
 			return constants.JudgeFlagWA, fmt.Sprintf(
 				"WA: leftPos=%d, rightPos=%d, leftLen=%d, rightLen=%d",
 				leftPos,
 				rightPos,
 				useroutLen,
 				answerLen,
-			)
+			), diffPosition{leftPos, rightPos}
 		}
 		leftPos++
 	}
 	// 如果右游标没跑完
 	for rightPos < answerLen {
-		rightByte = answerBuffer[rightPos]
-		if !isSpaceChar(rightByte) {
+		if !isSpaceChar(answerBuffer[rightPos]) {
 			return constants.JudgeFlagWA, fmt.Sprintf(
 				"WA: leftPos=%d, rightPos=%d, leftLen=%d, rightLen=%d",
 				leftPos,
 				rightPos,
 				useroutLen,
 				answerLen,
-			)
+			), diffPosition{leftPos, rightPos}
 		}
 		rightPos++
 	}
-	// 左右匹配，说明AC
+	// 左右匹配，说明AC；严格检查可以排除空白字符的顺序不一致也是AC的情况
 	// if left cursor's position equals right cursor's, means Accepted.
 	if leftPos == rightPos {
-		return constants.JudgeFlagAC, "AC!"
+		if !identical {
+			return constants.JudgeFlagPE, "Strict check: Presentation Error.", firstDifference(useroutBuffer, answerBuffer)
+		}
+		return constants.JudgeFlagAC, "Accepted.", diffPosition{}
 	}
 	return constants.JudgeFlagPE, fmt.Sprintf(
 		"PE: leftPos=%d, rightPos=%d, leftLen=%d, rightLen=%d",
@@ -191,7 +210,7 @@ func charDiffIoUtil(useroutBuffer, answerBuffer []byte, useroutLen, answerLen in
 		rightPos,
 		useroutLen,
 		answerLen,
-	)
+	), firstDifference(useroutBuffer, answerBuffer)
 }
 
 // DiffText Compare the text
@@ -214,23 +233,24 @@ func (session *JudgeSession) DiffText(result *commonStructs.TestCaseResult) erro
 	answerLen := answerInfo.Size()
 
 	sizeText := fmt.Sprintf("tcLen=%d, ansLen=%d", answerLen, useroutLen)
+	mode, absEps, relEps := session.compareOptions()
 
-	var useroutBuffer, answerBuffer []byte
-	errText := ""
-
-	answerBuffer, errText, err = readFileWithTry(path.Join(session.ConfigDir, result.Output), "answer", 3)
+	// 输出文件可能很大，映射到内存里比较，避免同时评测的时候占用太多内存
+	answerBuffer, unmapAnswer, errText, err := mapFileWithTry(path.Join(session.ConfigDir, result.Output), "answer", 3)
 	if err != nil {
 		result.JudgeResult = constants.JudgeFlagSE
 		result.TextDiffLog = errText
 		return err
 	}
+	defer unmapAnswer()
 
-	useroutBuffer, errText, err = readFileWithTry(path.Join(session.SessionDir, result.ProgramOut), "userout", 3)
+	useroutBuffer, unmapUserout, errText, err := mapFileWithTry(path.Join(session.SessionDir, result.ProgramOut), "userout", 3)
 	if err != nil {
 		result.JudgeResult = constants.JudgeFlagSE
 		result.TextDiffLog = errText
 		return err
 	}
+	defer unmapUserout()
 
 	if useroutLen == 0 && answerLen == 0 {
 		// Empty File AC
@@ -238,7 +258,9 @@ func (session *JudgeSession) DiffText(result *commonStructs.TestCaseResult) erro
 		result.TextDiffLog = sizeText + "; Accepted with zero size."
 		return nil
 	} else if useroutLen > 0 && answerLen > 0 {
-		if (useroutLen > int64(session.JudgeConfig.FileSizeLimit)) || (useroutLen >= answerLen*2) {
+		// float模式下数字的格式可以不一样（如1和1.000000），不检查输出是否超过参考答案的2倍
+		doubled := mode != constants.CompareModeFloat && useroutLen >= answerLen*2
+		if (useroutLen > int64(session.JudgeConfig.FileSizeLimit)) || doubled {
 			// OLE
 			result.JudgeResult = constants.JudgeFlagOLE
 			if useroutLen > int64(session.JudgeConfig.FileSizeLimit) {
@@ -252,30 +274,63 @@ func (session *JudgeSession) DiffText(result *commonStructs.TestCaseResult) erro
 		// WTF?
 		result.JudgeResult = constants.JudgeFlagWA
 		result.TextDiffLog = sizeText + "; WA: less then zero size."
+		result.DiffReport = newDiffReport(useroutBuffer, answerBuffer, diffPosition{}, false, result.Visible)
 		return nil
 	}
 
-	rel, logText := charDiffIoUtil(useroutBuffer, answerBuffer, useroutLen, answerLen)
+	if mode == constants.CompareModeFloat {
+		rel, logText, at := floatDiff(useroutBuffer, answerBuffer, absEps, relEps, result.Visible)
+		result.JudgeResult = rel
+		if rel == constants.JudgeFlagWA {
+			result.SameLines, result.TotalLines = floatLineDiff(useroutBuffer, answerBuffer, absEps, relEps)
+			result.DiffReport = newDiffReport(useroutBuffer, answerBuffer, at, false, result.Visible)
+		}
+		result.TextDiffLog = sizeText + "; " + logText
+		return nil
+	}
+
+	rel, logText, at := charDiff(useroutBuffer, answerBuffer)
 	result.JudgeResult = rel
+	if rel == constants.JudgeFlagWA {
+		result.SameLines, result.TotalLines = lineDiff(useroutBuffer, answerBuffer)
+	}
+	if rel != constants.JudgeFlagAC {
+		result.DiffReport = newDiffReport(useroutBuffer, answerBuffer, at, rel == constants.JudgeFlagPE, result.Visible)
+	}
+	result.TextDiffLog = sizeText + "; " + logText
+	return nil
+}
 
-	if rel != constants.JudgeFlagWA {
-		// PE or AC or SE
-		if rel == constants.JudgeFlagAC {
-			// AC 时执行强制检查，可以排除空白字符的顺序不一致也是AC的情况
-			sret := strictDiff(useroutBuffer, answerBuffer, useroutLen, answerLen)
-			if !sret {
-				result.JudgeResult = constants.JudgeFlagPE
-				logText = "Strict check: Presentation Error."
-			} else {
-				logText = "Accepted."
-			}
+// 运行内置的判题程序，判题信息写入SPJMsg
+func (session *JudgeSession) runBuiltinChecker(result *commonStructs.TestCaseResult) error {
+	check, err := checker.Get(session.JudgeConfig.SpecialJudge.Builtin)
+	if err != nil {
+		return err
+	}
+	files := make([]*os.File, 0, 3)
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
 		}
-	} else {
-		// WA
-		sameLines, totalLines := lineDiff(session, result)
-		result.SameLines = sameLines
-		result.TotalLines = totalLines
+	}()
+	for _, name := range []string{
+		path.Join(session.ConfigDir, result.Input),
+		path.Join(session.SessionDir, result.ProgramOut),
+		path.Join(session.ConfigDir, result.Output),
+	} {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
 	}
-	result.TextDiffLog = sizeText + "; " + logText
+	rst := check(files[0], files[1], files[2])
+	result.JudgeResult = rst.Verdict
+	result.SPJMsg = rst.Message
+	// 隐藏的测试数据不能通过判题信息泄露选手输出和参考答案的内容
+	if !result.Visible && rst.Redacted != "" {
+		result.SPJMsg = rst.Redacted
+	}
+	session.Logger.Infof("Builtin checker result: %s, %s", constants.FlagMeansMap[rst.Verdict], result.SPJMsg)
 	return nil
 }
@@ -0,0 +1,184 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package executor
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/LanceLRQ/deer-executor/v2/common/constants"
+	"github.com/pkg/errors"
+	"math"
+	"strconv"
+)
+
+// 计算浮点数误差时允许的舍入误差
+const floatRoundingError = 1e-15
+
+// 获取文本比较方式，float模式下两个误差都没有设置时使用默认值
+func (session *JudgeSession) compareOptions() (mode string, absEps, relEps float64) {
+	opts := session.JudgeConfig.Compare
+	mode = opts.Mode
+	if mode == "" {
+		mode = constants.CompareModeText
+	}
+	absEps, relEps = opts.AbsoluteEpsilon, opts.RelativeEpsilon
+	if absEps == 0 && relEps == 0 {
+		absEps, relEps = constants.CompareDefaultEpsilon, constants.CompareDefaultEpsilon
+	}
+	return
+}
+
+// 检查文本比较方式的设置
+func (session *JudgeSession) checkCompareOptions() error {
+	opts := session.JudgeConfig.Compare
+	switch opts.Mode {
+	case "", constants.CompareModeText, constants.CompareModeFloat:
+	default:
+		return errors.Errorf("unknown compare mode: %s", opts.Mode)
+	}
+	if opts.AbsoluteEpsilon < 0 || opts.RelativeEpsilon < 0 {
+		return errors.Errorf("compare epsilon must not be negative")
+	}
+	return nil
+}
+
+// 比较两个单词：参考答案是数字时允许有误差，否则必须完全一致
+// 返回是否一致、参考答案是否是数字，以及数字的绝对误差和相对误差（参考答案为0时相对误差等于绝对误差）
+func compareFloatToken(expected, found []byte, absEps, relEps float64) (ok, numeric bool, absErr, relErr float64) {
+	e, err := strconv.ParseFloat(string(expected), 64)
+	if err != nil {
+		return bytes.Equal(expected, found), false, 0, 0
+	}
+	f, err := strconv.ParseFloat(string(found), 64)
+	if err != nil {
+		return false, true, math.NaN(), math.NaN()
+	}
+	if math.IsNaN(e) || math.IsInf(e, 0) {
+		same := (math.IsNaN(e) && math.IsNaN(f)) || (math.IsInf(e, 0) && math.IsInf(f, 0) && (e > 0) == (f > 0))
+		if same {
+			return true, true, 0, 0
+		}
+		return false, true, math.Inf(1), math.Inf(1)
+	}
+	absErr = math.Abs(f - e)
+	relErr = absErr
+	if e != 0 {
+		relErr = absErr / math.Abs(e)
+	}
+	ok = absErr <= absEps+floatRoundingError || (e != 0 && absErr <= relEps*math.Abs(e)+floatRoundingError)
+	return ok, true, absErr, relErr
+}
+
+// 读取下一个单词（按空白字符切分），没有的时候返回nil
+func nextToken(buf []byte, pos *int) []byte {
+	for *pos < len(buf) && isSpaceChar(buf[*pos]) {
+		*pos++
+	}
+	start := *pos
+	for *pos < len(buf) && !isSpaceChar(buf[*pos]) {
+		*pos++
+	}
+	if start == *pos {
+		return nil
+	}
+	return buf[start:*pos]
+}
+
+// 统计剩下的单词数
+func countTokens(buf []byte, pos int) int {
+	count := 0
+	for nextToken(buf, &pos) != nil {
+		count++
+	}
+	return count
+}
+
+// 逐个比较单词，数字允许有误差；WA的时候日志里记录第一个不一致的单词的位置（从0开始）和误差，
+// visible为false（隐藏的测试数据）时不记录单词的内容
+// Compare each token, numbers with epsilon
+func floatDiff(useroutBuffer, answerBuffer []byte, absEps, relEps float64, visible bool) (rel int, logtext string, at diffPosition) {
+	leftPos, rightPos := 0, 0
+	for index := 0; ; index++ {
+		userout, answer := nextToken(useroutBuffer, &leftPos), nextToken(answerBuffer, &rightPos)
+		// 不一致的单词的开始位置
+		at = diffPosition{int64(leftPos - len(userout)), int64(rightPos - len(answer))}
+		if userout == nil || answer == nil {
+			if userout == nil && answer == nil {
+				return constants.JudgeFlagAC, "Accepted.", diffPosition{}
+			}
+			useroutTokens, answerTokens := index, index
+			if userout != nil {
+				useroutTokens += 1 + countTokens(useroutBuffer, leftPos)
+			} else {
+				answerTokens += 1 + countTokens(answerBuffer, rightPos)
+			}
+			return constants.JudgeFlagWA, fmt.Sprintf(
+				"WA: at token=%d, useroutTokens=%d, answerTokens=%d",
+				index,
+				useroutTokens,
+				answerTokens,
+			), at
+		}
+		ok, numeric, absErr, relErr := compareFloatToken(answer, userout, absEps, relEps)
+		if ok {
+			continue
+		}
+		tokens := ""
+		if visible {
+			tokens = fmt.Sprintf(", expected=%q, found=%q", truncateToken(answer), truncateToken(userout))
+		}
+		if !numeric {
+			return constants.JudgeFlagWA, fmt.Sprintf("WA: at token=%d%s", index, tokens), at
+		}
+		if math.IsNaN(absErr) {
+			return constants.JudgeFlagWA, fmt.Sprintf("WA: at token=%d%s, not a number", index, tokens), at
+		}
+		return constants.JudgeFlagWA, fmt.Sprintf(
+			"WA: at token=%d%s, absError=%.6g, relError=%.6g",
+			index,
+			tokens,
+			absErr,
+			relErr,
+		), at
+	}
+}
+
+// 逐行比较，数字允许有误差，获取错误行数（与lineDiff一样跳过空行）
+// Compare each line with epsilon, to find out the number of wrong line
+func floatLineDiff(useroutBuffer, answerBuffer []byte, absEps, relEps float64) (sameLines int, totalLines int) {
+	leftPos, rightPos := 0, 0
+	for {
+		answer := nextNonBlankLine(answerBuffer, &rightPos)
+		if answer == nil {
+			return
+		}
+		totalLines++
+		userout := nextNonBlankLine(useroutBuffer, &leftPos)
+		if userout != nil && floatLineEqual(userout, answer, absEps, relEps) {
+			sameLines++
+		}
+	}
+}
+
+// 逐个比较一行里的单词，数字允许有误差
+func floatLineEqual(userout, answer []byte, absEps, relEps float64) bool {
+	leftPos, rightPos := 0, 0
+	for {
+		left, right := nextToken(userout, &leftPos), nextToken(answer, &rightPos)
+		if left == nil || right == nil {
+			return left == nil && right == nil
+		}
+		if ok, _, _, _ := compareFloatToken(right, left, absEps, relEps); !ok {
+			return false
+		}
+	}
+}
+
+// 过长的单词只保留开头
+func truncateToken(token []byte) string {
+	if len(token) > 32 {
+		return string(token[:32]) + "..."
+	}
+	return string(token)
+}
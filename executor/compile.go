@@ -1,7 +1,9 @@
 package executor
 
 import (
+	"context"
 	"fmt"
+	"github.com/LanceLRQ/deer-executor/v2/common/checker"
 	"github.com/LanceLRQ/deer-executor/v2/common/constants"
 	"github.com/LanceLRQ/deer-executor/v2/common/provider"
 	commonStructs "github.com/LanceLRQ/deer-executor/v2/common/structs"
@@ -73,7 +75,7 @@ func (session *JudgeSession) GetCompiler(codeStr string) (provider.CodeCompilePr
 }
 
 // 编译目标程序
-func (session *JudgeSession) compileTargetProgram(judgeResult *commonStructs.JudgeResult) error {
+func (session *JudgeSession) compileTargetProgram(ctx context.Context, judgeResult *commonStructs.JudgeResult) error {
 	// 获取对应的编译器提供程序
 	compiler, err := session.GetCompiler(session.CodeStr)
 	if err != nil {
@@ -85,8 +87,12 @@ func (session *JudgeSession) compileTargetProgram(judgeResult *commonStructs.Jud
 
 	// 编译程序
 	session.Logger.Infof("Do complie or syntax checkup, Language: %s", session.CodeLangName)
-	success, ceinfo := compiler.Compile()
+	success, ceinfo := compiler.Compile(ctx)
 	if !success {
+		if ctx.Err() != nil {
+			// 编译器是因为评测被取消而被杀死的
+			return ctx.Err()
+		}
 		judgeResult.JudgeResult = constants.JudgeFlagCE
 		judgeResult.CeInfo = ceinfo
 		err = errors.Errorf("compile error:\n%s", ceinfo)
@@ -103,7 +109,15 @@ func (session *JudgeSession) compileTargetProgram(judgeResult *commonStructs.Jud
 // 编译裁判程序
 // 如果有已经编译好的裁判程序，则直接返回这个程序
 // 打包的时候不会打包二进制文件，重新编译一次
-func (session *JudgeSession) compileJudgerProgram(judgeResult *commonStructs.JudgeResult) error {
+func (session *JudgeSession) compileJudgerProgram(ctx context.Context, judgeResult *commonStructs.JudgeResult) error {
+	// 内置的判题程序不需要编译
+	if session.JudgeConfig.SpecialJudge.Builtin != "" {
+		if session.JudgeConfig.SpecialJudge.Mode != constants.SpecialJudgeModeChecker {
+			return errors.Errorf("builtin checker only support checker mode")
+		}
+		_, err := checker.Get(session.JudgeConfig.SpecialJudge.Builtin)
+		return err
+	}
 	// 检查是否存在已经编译好的裁判程序
 	cType := "checker"
 	if session.JudgeConfig.SpecialJudge.Mode == 2 {
@@ -150,6 +164,7 @@ func (session *JudgeSession) compileJudgerProgram(judgeResult *commonStructs.Jud
 	}
 	session.Logger.Infof("Complie special judge checker, Language: %s", config.SpecialJudge.CheckerLang)
 	compileTarget, err := CompileSpecialJudgeCodeFile(
+		ctx,
 		config.SpecialJudge.Checker,
 		config.SpecialJudge.Name,
 		binRoot,
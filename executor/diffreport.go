@@ -0,0 +1,162 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package executor
+
+import (
+	"bytes"
+	"fmt"
+	commonStructs "github.com/LanceLRQ/deer-executor/v2/common/structs"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	diffExcerptLength = 64 // 比较报告里每一行最多显示的字节数
+	diffContextLines  = 2  // 比较报告里第一处不一致的行前后各显示的行数
+)
+
+// 第一处不一致的位置（分别是选手输出和参考答案里的字节偏移）
+type diffPosition struct {
+	userout int64
+	answer  int64
+}
+
+// 找出两边第一个不一样的字节
+func firstDifference(useroutBuffer, answerBuffer []byte) diffPosition {
+	pos := 0
+	for pos < len(useroutBuffer) && pos < len(answerBuffer) && useroutBuffer[pos] == answerBuffer[pos] {
+		pos++
+	}
+	return diffPosition{int64(pos), int64(pos)}
+}
+
+// 生成比较报告，whitespace表示显示空白字符（PE的时候）；隐藏的测试数据只报告行号和列号
+func newDiffReport(useroutBuffer, answerBuffer []byte, at diffPosition, whitespace, visible bool) *commonStructs.DiffReport {
+	answerLine, answerStart, answerPos := locateLine(answerBuffer, at.answer)
+	useroutLine, useroutStart, useroutPos := locateLine(useroutBuffer, at.userout)
+	report := &commonStructs.DiffReport{
+		Line:         answerLine,
+		ActualLine:   useroutLine,
+		Column:       answerPos - answerStart + 1,
+		ActualColumn: useroutPos - useroutStart + 1,
+		Whitespace:   whitespace,
+		Hidden:       !visible,
+	}
+	if !visible {
+		return report
+	}
+
+	answerLines, answerBefore := surroundingLines(answerBuffer, answerStart)
+	useroutLines, useroutBefore := surroundingLines(useroutBuffer, useroutStart)
+	// 不一致的行只显示不一致的位置附近的内容（已经读完的时候为空）
+	if answerBefore < len(answerLines) {
+		report.Expected = excerptLine(answerLines[answerBefore], report.Column-1, whitespace)
+	}
+	if useroutBefore < len(useroutLines) {
+		report.Actual = excerptLine(useroutLines[useroutBefore], report.ActualColumn-1, whitespace)
+	}
+	report.Context = make([]commonStructs.DiffContextLine, 0, diffContextLines*2+1)
+	for offset := -diffContextLines; offset <= diffContextLines; offset++ {
+		ctx := commonStructs.DiffContextLine{}
+		if i := answerBefore + offset; i >= 0 && i < len(answerLines) {
+			ctx.Line = answerLine + offset
+			ctx.Expected = excerptLine(answerLines[i], 0, whitespace)
+		}
+		if i := useroutBefore + offset; i >= 0 && i < len(useroutLines) {
+			ctx.ActualLine = useroutLine + offset
+			ctx.Actual = excerptLine(useroutLines[i], 0, whitespace)
+		}
+		if ctx.Line == 0 && ctx.ActualLine == 0 {
+			continue
+		}
+		report.Context = append(report.Context, ctx)
+	}
+	return report
+}
+
+// 获取pos所在的行号（从1开始）、这一行的开始位置，以及不超出范围的pos
+func locateLine(buf []byte, pos int64) (line, start, clamped int) {
+	clamped = int(pos)
+	if clamped > len(buf) {
+		clamped = len(buf)
+	}
+	line = bytes.Count(buf[:clamped], []byte("\n")) + 1
+	start = bytes.LastIndexByte(buf[:clamped], '\n') + 1
+	return line, start, clamped
+}
+
+// 获取从start开始的一行以及它前后各diffContextLines行（包含行尾的换行符），同时返回它前边的行数
+func surroundingLines(buf []byte, start int) ([][]byte, int) {
+	lines := make([][]byte, 0, diffContextLines*2+1)
+	before := 0
+	for p := start; before < diffContextLines && p > 0; before++ {
+		prev := bytes.LastIndexByte(buf[:p-1], '\n') + 1
+		lines = append([][]byte{buf[prev:p]}, lines...)
+		p = prev
+	}
+	for p, i := start, 0; i <= diffContextLines && p < len(buf); i++ {
+		end := bytes.IndexByte(buf[p:], '\n')
+		if end < 0 {
+			end = len(buf) - p - 1
+		}
+		lines = append(lines, buf[p:p+end+1])
+		p += end + 1
+	}
+	return lines, before
+}
+
+// 截取一行里column附近的内容并转义，过长的部分用...代替
+func excerptLine(line []byte, column int, whitespace bool) string {
+	newline := bytes.HasSuffix(line, []byte("\n"))
+	if newline {
+		line = line[:len(line)-1]
+	}
+	start, end := 0, len(line)
+	if len(line) > diffExcerptLength {
+		start = column - diffExcerptLength/2
+		if start > len(line)-diffExcerptLength {
+			start = len(line) - diffExcerptLength
+		}
+		if start < 0 {
+			start = 0
+		}
+		end = start + diffExcerptLength
+	}
+	var sb strings.Builder
+	if start > 0 {
+		sb.WriteString("...")
+	}
+	escapeText(&sb, line[start:end], whitespace)
+	if end < len(line) {
+		sb.WriteString("...")
+	} else if whitespace && newline {
+		sb.WriteString("↵")
+	}
+	return sb.String()
+}
+
+// 转义不可见的字符；whitespace为true时把空格、制表符和回车显示为·、→和␍
+func escapeText(sb *strings.Builder, text []byte, whitespace bool) {
+	for len(text) > 0 {
+		r, size := utf8.DecodeRune(text)
+		switch {
+		case r == utf8.RuneError && size == 1:
+			sb.WriteString(fmt.Sprintf("\\x%02x", text[0]))
+		case whitespace && r == ' ':
+			sb.WriteString("·")
+		case whitespace && r == '\t':
+			sb.WriteString("→")
+		case whitespace && r == '\r':
+			sb.WriteString("␍")
+		case r == ' ' || r == '\t' || (unicode.IsPrint(r) && r != '\\'):
+			sb.WriteRune(r)
+		default:
+			quoted := strconv.QuoteRune(r)
+			sb.WriteString(quoted[1 : len(quoted)-1])
+		}
+		text = text[size:]
+	}
+}
@@ -4,19 +4,36 @@
 package executor
 
 import (
+	"context"
+	"fmt"
 	"github.com/LanceLRQ/deer-executor/v2/common/constants"
+	"github.com/LanceLRQ/deer-executor/v2/common/sandbox"
 	commonStructs "github.com/LanceLRQ/deer-executor/v2/common/structs"
 	"github.com/pkg/errors"
 	"os"
 	"path"
+	"path/filepath"
 	"strconv"
 )
 
 // JudgeOnce 基于JudgeOptions进行评测调度
 func (session *JudgeSession) JudgeOnce(judgeResult *commonStructs.TestCaseResult) {
+	session.judgeOnce(context.Background(), judgeResult)
+}
+
+// ctx被取消时杀死正在运行的程序
+func (session *JudgeSession) judgeOnce(ctx context.Context, judgeResult *commonStructs.TestCaseResult) {
+	if session.JudgeConfig.OutputOnly {
+		session.judgeOutputOnly(ctx, judgeResult)
+		return
+	}
+	if session.JudgeConfig.SpecialJudge.Builtin != "" {
+		session.judgeWithBuiltinChecker(ctx, judgeResult)
+		return
+	}
 	switch session.JudgeConfig.SpecialJudge.Mode {
 	case constants.SpecialJudgeModeDisabled:
-		pinfo, err := session.runNormalJudge(judgeResult)
+		pinfo, err := session.runNormalJudge(ctx, judgeResult)
 		if err != nil {
 			judgeResult.JudgeResult = constants.JudgeFlagSE
 			judgeResult.SeInfo = err.Error()
@@ -40,7 +57,7 @@ func (session *JudgeSession) JudgeOnce(judgeResult *commonStructs.TestCaseResult
 		}
 
 	case constants.SpecialJudgeModeChecker, constants.SpecialJudgeModeInteractive:
-		tinfo, jinfo, err := session.runSpecialJudge(judgeResult)
+		tinfo, jinfo, err := session.runSpecialJudge(ctx, judgeResult)
 		if err != nil {
 			judgeResult.JudgeResult = constants.JudgeFlagSE
 			judgeResult.SeInfo = err.Error()
@@ -72,6 +89,28 @@ func (session *JudgeSession) JudgeOnce(judgeResult *commonStructs.TestCaseResult
 	return
 }
 
+// 使用内置的判题程序评测：正常运行目标程序，AC的时候在评测机的进程里运行判题程序
+func (session *JudgeSession) judgeWithBuiltinChecker(ctx context.Context, judgeResult *commonStructs.TestCaseResult) {
+	pinfo, err := session.runNormalJudge(ctx, judgeResult)
+	if err != nil {
+		judgeResult.JudgeResult = constants.JudgeFlagSE
+		judgeResult.SeInfo = err.Error()
+		session.Logger.Error(err.Error())
+		return
+	}
+	session.saveExitRusage(judgeResult, pinfo, false)
+	session.analysisExitStatus(judgeResult, pinfo, false)
+	if judgeResult.JudgeResult == constants.JudgeFlagAC {
+		session.Logger.Infof("Run builtin checker: %s.", session.JudgeConfig.SpecialJudge.Builtin)
+		err = session.runBuiltinChecker(judgeResult)
+		if err != nil {
+			judgeResult.JudgeResult = constants.JudgeFlagSE
+			judgeResult.SeInfo = err.Error()
+			session.Logger.Error(err.Error())
+		}
+	}
+}
+
 // 检查Input、Output是否存在
 func checkTestCaseInputOutput(tcase commonStructs.TestCase, configDir string) error {
 	_, err := os.Stat(path.Join(configDir, tcase.Input))
@@ -86,7 +125,7 @@ func checkTestCaseInputOutput(tcase commonStructs.TestCase, configDir string) er
 }
 
 // 对一组测试数据运行一次评测
-func (session *JudgeSession) runOneCase(config *commonStructs.JudgeConfiguration, tc commonStructs.TestCase, id string) *commonStructs.TestCaseResult {
+func (session *JudgeSession) runOneCase(ctx context.Context, config *commonStructs.JudgeConfiguration, tc commonStructs.TestCase, id string) *commonStructs.TestCaseResult {
 	session.Logger.Infof("Run test case: %s", id)
 
 	var err error
@@ -96,6 +135,7 @@ func (session *JudgeSession) runOneCase(config *commonStructs.JudgeConfiguration
 	// 创建相关的文件路径
 	tcResult.Input = tc.Input
 	tcResult.Output = tc.Output
+	tcResult.Visible = tc.Visible
 	tcResult.ProgramOut = id + "_program.out"
 	tcResult.ProgramError = id + "_program.err"
 	tcResult.CheckerOut = id + "_checker.out"
@@ -112,57 +152,140 @@ func (session *JudgeSession) runOneCase(config *commonStructs.JudgeConfiguration
 	}
 
 	// 运行judge程序
-	session.JudgeOnce(&tcResult)
+	session.judgeOnce(ctx, &tcResult)
+	if ctx.Err() != nil && tcResult.JudgeResult == constants.JudgeFlagSE {
+		// 程序是因为评测被取消而被杀死的
+		tcResult.JudgeResult = constants.JudgeFlagCancelled
+		tcResult.SeInfo = fmt.Sprintf("judgement cancelled: %s", ctx.Err().Error())
+	}
+	session.scoreTestCase(&tcResult, tc.Score)
 
+	flagName, ok := constants.FlagMeansMap[tcResult.JudgeResult]
+	if !ok {
+		flagName = "Unknown"
+	}
+	session.Logger.Infof("Test case %s's result is %s", id, flagName)
 	return &tcResult
 }
 
 // RunJudge 执行评测
 func (session *JudgeSession) RunJudge() commonStructs.JudgeResult {
+	return session.RunJudgeContext(context.Background())
+}
+
+// 评测被取消时把结果记为Cancelled
+func (session *JudgeSession) checkCancelled(ctx context.Context, judgeResult *commonStructs.JudgeResult) bool {
+	if ctx.Err() == nil {
+		return false
+	}
+	judgeResult.JudgeResult = constants.JudgeFlagCancelled
+	judgeResult.SeInfo = fmt.Sprintf("judgement cancelled: %s", ctx.Err().Error())
+	session.Logger.Warn(judgeResult.SeInfo)
+	return true
+}
+
+// RunJudgeContext 执行评测，ctx被取消时杀死正在运行的编译器和程序，结果记为Cancelled
+func (session *JudgeSession) RunJudgeContext(ctx context.Context) commonStructs.JudgeResult {
 	session.Logger.Info("Start Judgement")
 
 	// make judge result
 	judgeResult := commonStructs.JudgeResult{}
 	judgeResult.SessionID = session.SessionID
+	judgeResult.CPUCore = session.CPUCore
+	if session.CPUCore >= 0 {
+		session.Logger.Infof("Pinned to CPU core %d", session.CPUCore)
+	}
+
+	if _, err := sandbox.ParseMemoryStrategy(session.MemoryStrategy); err != nil {
+		judgeResult.JudgeResult = constants.JudgeFlagSE
+		judgeResult.SeInfo = err.Error()
+		judgeResult.JudgeLogs = session.Logger.GetLogs()
+		return judgeResult
+	}
+
+	for i := 0; i < len(session.JudgeConfig.TestCases); i++ {
+		if session.JudgeConfig.TestCases[i].Handle == "" {
+			session.JudgeConfig.TestCases[i].Handle = strconv.Itoa(i)
+		}
+	}
+	err := session.checkJudgePolicy()
+	if err == nil {
+		err = session.checkCompareOptions()
+	}
+	if err != nil {
+		judgeResult.JudgeResult = constants.JudgeFlagSE
+		judgeResult.SeInfo = err.Error()
+		judgeResult.JudgeLogs = session.Logger.GetLogs()
+		return judgeResult
+	}
+	subtaskOrder, err := session.sortSubtasks()
+	if err != nil {
+		judgeResult.JudgeResult = constants.JudgeFlagSE
+		judgeResult.SeInfo = err.Error()
+		judgeResult.JudgeLogs = session.Logger.GetLogs()
+		return judgeResult
+	}
 
-	// compile code
-	err := session.compileTargetProgram(&judgeResult)
+	if session.JudgeConfig.OutputOnly {
+		// 提交答案题：解压提交的输出文件
+		err = session.extractOutputs(&judgeResult)
+	} else {
+		// compile code
+		err = session.compileTargetProgram(ctx, &judgeResult)
+	}
 	if err != nil {
+		session.checkCancelled(ctx, &judgeResult)
 		judgeResult.JudgeLogs = session.Logger.GetLogs()
 		return judgeResult
 	}
 
 	if session.JudgeConfig.SpecialJudge.Mode > 0 {
 		// 如果需要特殊评测，则编译相关代码
-		err := session.compileJudgerProgram(&judgeResult)
+		err := session.compileJudgerProgram(ctx, &judgeResult)
 		if err != nil {
-			judgeResult.JudgeResult = constants.JudgeFlagSE
-			judgeResult.SeInfo = err.Error()
+			if !session.checkCancelled(ctx, &judgeResult) {
+				judgeResult.JudgeResult = constants.JudgeFlagSE
+				judgeResult.SeInfo = err.Error()
+			}
 			judgeResult.JudgeLogs = session.Logger.GetLogs()
 			return judgeResult
 		}
 	}
 
-	// 资源限制信息更新
-	updateLimitation(session)
+	// 让运行程序的用户可以在会话目录里写文件
+	err = session.chownSessionDir()
+	if err != nil {
+		judgeResult.JudgeResult = constants.JudgeFlagSE
+		judgeResult.SeInfo = err.Error()
+		judgeResult.JudgeLogs = session.Logger.GetLogs()
+		return judgeResult
+	}
+
+	// 资源限制信息更新（提交答案题不区分语言）
+	if !session.JudgeConfig.OutputOnly {
+		updateLimitation(session)
+	}
 
+	if session.CPUCore >= 0 && session.Parallelism > 1 {
+		// 绑定了核心时并发运行只会分时共用这一个核心，墙上时间变长，容易误判超时或空闲超限
+		session.Logger.Warnf("Test cases run one by one on the pinned cpu core %d.", session.CPUCore)
+		session.Parallelism = 1
+	}
 	session.Logger.Info("Ready for judgement")
+	var tcResults []*commonStructs.TestCaseResult
+	totalCases := len(session.JudgeConfig.TestCases)
+	if len(session.JudgeConfig.Subtasks) > 0 {
+		tcResults, judgeResult.Subtasks, totalCases = session.runSubtasks(ctx, subtaskOrder)
+	} else {
+		tcResults = session.runTestCases(ctx, session.JudgeConfig.TestCases, session.caseStopper())
+	}
 	// Init exit code
 	exitCodes := make([]int, 0, 1)
-	for i := 0; i < len(session.JudgeConfig.TestCases); i++ {
-		if session.JudgeConfig.TestCases[i].Handle == "" {
-			session.JudgeConfig.TestCases[i].Handle = strconv.Itoa(i)
-		}
-		id := session.JudgeConfig.TestCases[i].Handle
-
-		tcResult := session.runOneCase(&session.JudgeConfig, session.JudgeConfig.TestCases[i], id)
-
-		flagName, ok := constants.FlagMeansMap[tcResult.JudgeResult]
-		if !ok {
-			flagName = "Unknown"
+	// 按测试数据的顺序汇总结果，并行运行时也和依次运行的结果一致
+	for _, tcResult := range tcResults {
+		if tcResult == nil {
+			break
 		}
-		session.Logger.Infof("This case's result is " + flagName)
-
 		isFault := session.isDisastrousFault(&judgeResult, tcResult)
 		judgeResult.TestCases = append(judgeResult.TestCases, *tcResult)
 		judgeResult.MemoryUsed = Max32(tcResult.MemoryUsed, judgeResult.MemoryUsed)
@@ -175,20 +298,11 @@ func (session *JudgeSession) RunJudge() commonStructs.JudgeResult {
 		if isFault {
 			break
 		}
-
-		//判定是否继续判题
-		keep := false
-		if tcResult.JudgeResult == constants.JudgeFlagAC || tcResult.JudgeResult == constants.JudgeFlagPE {
-			keep = true
-		} else if !session.JudgeConfig.StrictMode && tcResult.JudgeResult == constants.JudgeFlagWA {
-			keep = true
-		}
-		if !keep {
-			break
-		}
 	}
 	// 计算最终结果
-	session.generateFinallyResult(&judgeResult, exitCodes)
+	session.generateFinallyResult(&judgeResult, exitCodes, totalCases)
+	session.generateScore(&judgeResult)
+	session.checkCancelled(ctx, &judgeResult)
 
 	// Log
 	if judgeResult.JudgeResult == constants.JudgeFlagAC {
@@ -206,6 +320,39 @@ func (session *JudgeSession) RunJudge() commonStructs.JudgeResult {
 	return judgeResult
 }
 
+// 获取运行目标程序和判题程序的用户，uid为0表示不切换
+func (session *JudgeSession) credential() (int, int) {
+	uid, gid := session.UID, session.GID
+	if uid <= 0 {
+		uid, gid = session.JudgeConfig.UID, 0
+	}
+	if uid <= 0 {
+		return 0, 0
+	}
+	if gid <= 0 {
+		gid = uid
+	}
+	return uid, gid
+}
+
+// 把会话目录（包括编译生成的文件）的所有者改为运行程序的用户
+func (session *JudgeSession) chownSessionDir() error {
+	uid, gid := session.credential()
+	if uid <= 0 {
+		return nil
+	}
+	err := filepath.Walk(session.SessionDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Lchown(p, uid, gid)
+	})
+	if err != nil {
+		return errors.Errorf("change owner of session dir error: %s", err.Error())
+	}
+	return nil
+}
+
 // 从资源限制的参数列表里按语言获取相关信息，并作为当前的资源限制参数。
 func updateLimitation(session *JudgeSession) {
 	langName := session.Compiler.GetName()
@@ -220,7 +367,26 @@ func updateLimitation(session *JudgeSession) {
 		session.JudgeConfig.MemoryLimit = limitation.MemoryLimit + memoryLimitExtend
 		session.JudgeConfig.RealTimeLimit = limitation.RealTimeLimit
 		session.JudgeConfig.FileSizeLimit = limitation.FileSizeLimit
-		return
+		if limitation.ProcessLimit != 0 {
+			session.JudgeConfig.ProcessLimit = limitation.ProcessLimit
+		}
+		if limitation.StackLimit != 0 {
+			session.JudgeConfig.StackLimit = limitation.StackLimit
+		}
+		if limitation.OpenFilesLimit != 0 {
+			session.JudgeConfig.OpenFilesLimit = limitation.OpenFilesLimit
+		}
+		if limitation.CoreDump {
+			session.JudgeConfig.CoreDump = true
+		}
+	} else {
+		session.JudgeConfig.MemoryLimit = session.JudgeConfig.MemoryLimit + memoryLimitExtend
+	}
+	// 没有设置进程/线程数限制时，按语言使用默认值
+	if session.JudgeConfig.ProcessLimit == 0 {
+		session.JudgeConfig.ProcessLimit = constants.ProcessLimit
+		if processLimit, ok := constants.ProcessLimitForLanguage[langName]; ok {
+			session.JudgeConfig.ProcessLimit = processLimit
+		}
 	}
-	session.JudgeConfig.MemoryLimit = session.JudgeConfig.MemoryLimit + memoryLimitExtend
 }
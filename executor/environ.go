@@ -0,0 +1,92 @@
+package executor
+
+import (
+	"fmt"
+	commonStructs "github.com/LanceLRQ/deer-executor/v2/common/structs"
+	"hash/fnv"
+	"os"
+	"sort"
+	"strings"
+)
+
+// 每次运行都会注入的环境变量
+const (
+	EnvOnlineJudge = "ONLINE_JUDGE"
+	EnvRandomSeed  = "RANDOM_SEED"
+)
+
+// 合并语言和题目的环境变量策略，题目的设置优先
+func mergeEnvironmentPolicy(lang, problem commonStructs.EnvironmentPolicy) commonStructs.EnvironmentPolicy {
+	policy := commonStructs.EnvironmentPolicy{
+		Inherit:    append(append([]string{}, lang.Inherit...), problem.Inherit...),
+		Fixed:      map[string]string{},
+		RandomSeed: lang.RandomSeed,
+	}
+	for k, v := range lang.Fixed {
+		policy.Fixed[k] = v
+	}
+	for k, v := range problem.Fixed {
+		policy.Fixed[k] = v
+	}
+	if problem.RandomSeed != 0 {
+		policy.RandomSeed = problem.RandomSeed
+	}
+	return policy
+}
+
+// 生成目标程序的环境变量：白名单里的评测机环境变量、固定值，以及ONLINE_JUDGE和RANDOM_SEED
+// 同一份策略和测试数据总是生成相同的环境变量
+func buildEnviron(policy commonStructs.EnvironmentPolicy, handle string) []string {
+	values := map[string]string{}
+	keys := make([]string, 0, len(policy.Inherit)+len(policy.Fixed)+2)
+	set := func(k, v string) {
+		if !isValidEnvironName(k) {
+			return
+		}
+		if _, ok := values[k]; !ok {
+			keys = append(keys, k)
+		}
+		values[k] = v
+	}
+	for _, k := range policy.Inherit {
+		if v, ok := os.LookupEnv(k); ok {
+			set(k, v)
+		}
+	}
+	fixed := make([]string, 0, len(policy.Fixed))
+	for k := range policy.Fixed {
+		fixed = append(fixed, k)
+	}
+	sort.Strings(fixed)
+	for _, k := range fixed {
+		set(k, policy.Fixed[k])
+	}
+	seed := policy.RandomSeed
+	if seed == 0 {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(handle))
+		seed = int64(h.Sum32())
+	}
+	set(EnvOnlineJudge, "1")
+	set(EnvRandomSeed, fmt.Sprintf("%d", seed))
+
+	env := make([]string, 0, len(keys))
+	for _, k := range keys {
+		env = append(env, k+"="+values[k])
+	}
+	return env
+}
+
+// 获取目标程序的环境变量策略
+func (session *JudgeSession) getEnvironmentPolicy() commonStructs.EnvironmentPolicy {
+	var lang commonStructs.EnvironmentPolicy
+	if session.Compiler != nil {
+		lang = session.Compiler.GetEnvironmentPolicy()
+	}
+	return mergeEnvironmentPolicy(lang, session.JudgeConfig.Environment)
+}
+
+// 判断环境变量名是否合法
+func isValidEnvironName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, "=\x00")
+}
@@ -0,0 +1,193 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package executor
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"github.com/LanceLRQ/deer-executor/v2/common/constants"
+	commonStructs "github.com/LanceLRQ/deer-executor/v2/common/structs"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 提交的一个输出文件
+type outputFile struct {
+	name string
+	open func() (io.ReadCloser, error)
+}
+
+// 列出提交的所有输出文件，source可以是zip压缩包或者目录
+func listOutputFiles(source string) ([]outputFile, func(), error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, nil, errors.Errorf("read output archive error: %s", err.Error())
+	}
+	files := make([]outputFile, 0)
+	if info.IsDir() {
+		err = filepath.Walk(source, func(p string, fi os.FileInfo, err error) error {
+			if err != nil || fi.IsDir() {
+				return err
+			}
+			files = append(files, outputFile{name: p, open: func() (io.ReadCloser, error) {
+				return os.Open(p)
+			}})
+			return nil
+		})
+		if err != nil {
+			return nil, nil, errors.Errorf("read output directory error: %s", err.Error())
+		}
+		return files, func() {}, nil
+	}
+	zr, err := zip.OpenReader(source)
+	if err != nil {
+		return nil, nil, errors.Errorf("read output archive error: %s", err.Error())
+	}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		files = append(files, outputFile{name: f.Name, open: f.Open})
+	}
+	return files, func() { _ = zr.Close() }, nil
+}
+
+// 根据文件名找到对应的测试数据：文件名就是Handle，或者是Handle加上扩展名（如1.out）
+func outputHandle(name string, handles map[string]bool) (string, bool) {
+	base := path.Base(filepath.ToSlash(name))
+	if handles[base] {
+		return base, true
+	}
+	handle := strings.TrimSuffix(base, path.Ext(base))
+	return handle, handles[handle]
+}
+
+// 复制输出文件，超出文件大小限制的部分不复制（多复制一个字节，评测时按OLE处理）
+func copyOutputFile(f outputFile, dst string, limit int) error {
+	src, err := f.open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if limit > 0 {
+		_, err = io.CopyN(out, src, int64(limit)+1)
+		if err == io.EOF {
+			err = nil
+		}
+	} else {
+		_, err = io.Copy(out, src)
+	}
+	return err
+}
+
+// 提交答案题：把提交的输出文件复制到会话目录，作为每组测试数据的程序输出
+// 提交的内容有问题（不是zip压缩包、同一组测试数据有多个输出文件等）的时候按编译错误处理
+func (session *JudgeSession) extractOutputs(judgeResult *commonStructs.JudgeResult) error {
+	if session.JudgeConfig.SpecialJudge.Mode == constants.SpecialJudgeModeInteractive {
+		judgeResult.JudgeResult = constants.JudgeFlagSE
+		judgeResult.SeInfo = "output-only problem does not support interactive mode"
+		session.Logger.Error(judgeResult.SeInfo)
+		return errors.Errorf(judgeResult.SeInfo)
+	}
+	compileError := func(err error) error {
+		judgeResult.JudgeResult = constants.JudgeFlagCE
+		judgeResult.CeInfo = err.Error()
+		session.Logger.Error(err.Error())
+		return err
+	}
+	if session.CodeFile == "" {
+		return compileError(errors.Errorf("output-only problem requires an output archive"))
+	}
+	session.Logger.Infof("Extract outputs from: %s", session.CodeFile)
+
+	handles := map[string]bool{}
+	for _, tc := range session.JudgeConfig.TestCases {
+		handles[tc.Handle] = true
+	}
+	files, closeFiles, err := listOutputFiles(session.CodeFile)
+	if err != nil {
+		return compileError(err)
+	}
+	defer closeFiles()
+
+	extracted := map[string]string{}
+	for _, f := range files {
+		handle, ok := outputHandle(f.name, handles)
+		if !ok {
+			session.Logger.Warnf("Output file %s does not match any test case, ignored.", f.name)
+			continue
+		}
+		if prev, ok := extracted[handle]; ok {
+			return compileError(errors.Errorf("test case (%s) has more than one output file: %s, %s", handle, prev, f.name))
+		}
+		extracted[handle] = f.name
+		err = copyOutputFile(f, path.Join(session.SessionDir, handle+"_program.out"), session.JudgeConfig.FileSizeLimit)
+		if err != nil {
+			return compileError(errors.Errorf("extract output file (%s) error: %s", f.name, err.Error()))
+		}
+	}
+	session.Logger.Infof("Extracted %d output files.", len(extracted))
+	return nil
+}
+
+// 提交答案题：不运行程序，直接比较提交的输出文件或者交给判题程序
+func (session *JudgeSession) judgeOutputOnly(ctx context.Context, judgeResult *commonStructs.TestCaseResult) {
+	info, err := os.Stat(path.Join(session.SessionDir, judgeResult.ProgramOut))
+	if os.IsNotExist(err) {
+		judgeResult.JudgeResult = constants.JudgeFlagWA
+		judgeResult.TextDiffLog = "output file not submitted"
+		return
+	} else if err != nil {
+		judgeResult.JudgeResult = constants.JudgeFlagSE
+		judgeResult.SeInfo = err.Error()
+		session.Logger.Error(err.Error())
+		return
+	}
+	if session.JudgeConfig.FileSizeLimit > 0 && info.Size() > int64(session.JudgeConfig.FileSizeLimit) {
+		judgeResult.JudgeResult = constants.JudgeFlagOLE
+		judgeResult.TextDiffLog = fmt.Sprintf("output file larger then limitation: %d", session.JudgeConfig.FileSizeLimit)
+		return
+	}
+
+	spj := session.JudgeConfig.SpecialJudge
+	switch {
+	case spj.Builtin != "":
+		session.Logger.Infof("Run builtin checker: %s.", spj.Builtin)
+		err = session.runBuiltinChecker(judgeResult)
+	case spj.Mode == constants.SpecialJudgeModeChecker:
+		cctx, cancel := context.WithTimeout(ctx, time.Duration(session.Timeout)*time.Second)
+		defer cancel()
+		var jinfo *ProcessInfo
+		jinfo, err = runAsync(cctx, session, judgeResult, true)
+		if err != nil {
+			break
+		}
+		session.saveExitRusage(judgeResult, jinfo, true)
+		session.analysisExitStatus(judgeResult, jinfo, true)
+		// 支持按判题机的意愿进行文本比较
+		if judgeResult.JudgeResult == constants.JudgeFlagSpecialJudgeRequireChecker {
+			session.Logger.Infof("Run text checker.")
+			err = session.DiffText(judgeResult)
+		}
+	default:
+		session.Logger.Infof("Run text checker.")
+		err = session.DiffText(judgeResult)
+	}
+	if err != nil {
+		judgeResult.JudgeResult = constants.JudgeFlagSE
+		judgeResult.SeInfo = err.Error()
+		session.Logger.Error(err.Error())
+	}
+}
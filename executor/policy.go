@@ -0,0 +1,75 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package executor
+
+import (
+	"github.com/LanceLRQ/deer-executor/v2/common/constants"
+	commonStructs "github.com/LanceLRQ/deer-executor/v2/common/structs"
+	"github.com/pkg/errors"
+)
+
+// 获取评测策略，没有设置的项按strict_mode取默认值
+func (session *JudgeSession) judgePolicy() commonStructs.JudgePolicy {
+	policy := session.JudgeConfig.JudgePolicy
+	if policy.OnFailure == "" {
+		policy.OnFailure = constants.JudgePolicyRunAll
+		// 提交答案题的每组测试数据都是独立计分的，默认评测所有测试数据
+		if session.JudgeConfig.StrictMode && !session.JudgeConfig.OutputOnly {
+			policy.OnFailure = constants.JudgePolicyStop
+		}
+	}
+	if policy.PresentationError == "" {
+		policy.PresentationError = constants.JudgePolicyPEAccept
+		if session.JudgeConfig.StrictMode {
+			policy.PresentationError = constants.JudgePolicyPEContinue
+		}
+	}
+	if policy.Verdict == "" {
+		policy.Verdict = constants.JudgePolicyVerdictWorst
+	}
+	return policy
+}
+
+// 检查评测策略的设置
+func (session *JudgeSession) checkJudgePolicy() error {
+	policy := session.JudgeConfig.JudgePolicy
+	switch policy.OnFailure {
+	case "", constants.JudgePolicyStop, constants.JudgePolicyRunAll:
+	default:
+		return errors.Errorf("unknown judge policy on_failure: %s", policy.OnFailure)
+	}
+	switch policy.PresentationError {
+	case "", constants.JudgePolicyPEAccept, constants.JudgePolicyPEFail, constants.JudgePolicyPEContinue:
+	default:
+		return errors.Errorf("unknown judge policy presentation_error: %s", policy.PresentationError)
+	}
+	switch policy.Verdict {
+	case "", constants.JudgePolicyVerdictWorst, constants.JudgePolicyVerdictFirst:
+	default:
+		return errors.Errorf("unknown judge policy verdict: %s", policy.Verdict)
+	}
+	if policy.MaxFailures < 0 {
+		return errors.Errorf("judge policy max_failures must not be negative")
+	}
+	return nil
+}
+
+// 评测结果是否算作通过
+func (session *JudgeSession) casePassed(flag int) bool {
+	if flag == constants.JudgeFlagAC {
+		return true
+	}
+	return flag == constants.JudgeFlagPE && session.judgePolicy().PresentationError == constants.JudgePolicyPEAccept
+}
+
+// 按评测策略决定什么时候结束运行测试数据
+func (session *JudgeSession) caseStopper() caseStopper {
+	return caseStopper{
+		isFinal: session.isFinalCase,
+		isFailed: func(rst *commonStructs.TestCaseResult) bool {
+			return !session.casePassed(rst.JudgeResult)
+		},
+		maxFailures: session.judgePolicy().MaxFailures,
+	}
+}
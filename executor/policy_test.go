@@ -0,0 +1,36 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package executor
+
+import (
+	"github.com/LanceLRQ/deer-executor/v2/common/constants"
+	commonStructs "github.com/LanceLRQ/deer-executor/v2/common/structs"
+	"testing"
+)
+
+// Test: judge policy with test cases that have not finished
+// 依次运行时的各种策略已经由TestAPlusBProblemJudgePolicy覆盖，这里只检查并发运行和被取消时才会出现的情况
+func TestJudgePolicyUnfinished(t *testing.T) {
+	session := &JudgeSession{}
+	session.JudgeConfig.JudgePolicy = commonStructs.JudgePolicy{OnFailure: constants.JudgePolicyRunAll, MaxFailures: 2}
+	wa := &commonStructs.TestCaseResult{JudgeResult: constants.JudgeFlagWA}
+	ac := &commonStructs.TestCaseResult{JudgeResult: constants.JudgeFlagAC}
+	// 还没有完成的测试数据不计入失败数量
+	if got := session.caseStopper().stopIndex([]*commonStructs.TestCaseResult{wa, nil, ac, wa}, 4); got != 3 {
+		t.Fatalf("expect to stop at 3, got %d", got)
+		return
+	}
+	if got := session.caseStopper().stopIndex([]*commonStructs.TestCaseResult{wa, nil, ac, nil}, 4); got != 4 {
+		t.Fatalf("expect not to stop, got %d", got)
+		return
+	}
+	// 测试数据没有全部跑完却没有失败的测试数据时按WA处理
+	result := &commonStructs.JudgeResult{}
+	session.generateFinallyResult(result, []int{constants.JudgeFlagAC}, 2)
+	if result.JudgeResult != constants.JudgeFlagWA {
+		t.Fatalf("expect WA, got %d", result.JudgeResult)
+		return
+	}
+	t.Log("OK")
+}
@@ -5,49 +5,41 @@ package executor
 
 import (
 	"context"
+	"fmt"
 	"github.com/LanceLRQ/deer-executor/v2/common/constants"
-	"github.com/LanceLRQ/deer-executor/v2/common/sandbox/cmd"
+	"github.com/LanceLRQ/deer-executor/v2/common/sandbox"
+	"github.com/LanceLRQ/deer-executor/v2/common/sandbox/cgroup"
 	"github.com/LanceLRQ/deer-executor/v2/common/sandbox/forkexec"
+	"github.com/LanceLRQ/deer-executor/v2/common/sandbox/seccomp"
 	commonStructs "github.com/LanceLRQ/deer-executor/v2/common/structs"
 	"github.com/pkg/errors"
 	"log"
 	"os"
-	"os/exec"
 	"path"
 	"path/filepath"
 	"syscall"
 	"time"
 )
 
-// PArgs Start Process Arguments
-type PArgs struct {
-	Name string
-	Args []string
-	Attr *cmd.ProcAttr
-}
-
-// ExtraEnviron 额外需要被注入的环境变量
-var ExtraEnviron = []string{"PYTHONIOENCODING=utf-8"}
-
 // 运行目标程序
-func (session *JudgeSession) runNormalJudge(rst *commonStructs.TestCaseResult) (*ProcessInfo, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(session.Timeout)*time.Second)
+func (session *JudgeSession) runNormalJudge(ctx context.Context, rst *commonStructs.TestCaseResult) (*ProcessInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(session.Timeout)*time.Second)
 	defer cancel()
 	return runAsync(ctx, session, rst, false)
 }
 
 // 运行特殊评测
-func (session *JudgeSession) runSpecialJudge(rst *commonStructs.TestCaseResult) (*ProcessInfo, *ProcessInfo, error) {
+func (session *JudgeSession) runSpecialJudge(ctx context.Context, rst *commonStructs.TestCaseResult) (*ProcessInfo, *ProcessInfo, error) {
 	if session.JudgeConfig.SpecialJudge.Mode == constants.SpecialJudgeModeChecker {
 
 		// checker模式，用runAsync依次运行
-		ctx1, cancel1 := context.WithTimeout(context.Background(), time.Duration(session.Timeout)*time.Second)
+		ctx1, cancel1 := context.WithTimeout(ctx, time.Duration(session.Timeout)*time.Second)
 		defer cancel1()
 		answer, err := runAsync(ctx1, session, rst, false)
 		if err != nil {
 			return nil, nil, err
 		}
-		ctx2, cancel2 := context.WithTimeout(context.Background(), time.Duration(session.Timeout)*time.Second)
+		ctx2, cancel2 := context.WithTimeout(ctx, time.Duration(session.Timeout)*time.Second)
 		defer cancel2()
 		checker, err := runAsync(ctx2, session, rst, true)
 		if err != nil {
@@ -57,7 +49,7 @@ func (session *JudgeSession) runSpecialJudge(rst *commonStructs.TestCaseResult)
 
 	} else if session.JudgeConfig.SpecialJudge.Mode == constants.SpecialJudgeModeInteractive {
 		// 交互模式
-		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(session.Timeout)*time.Second)
+		ctx, cancel := context.WithTimeout(ctx, time.Duration(session.Timeout)*time.Second)
 		defer cancel()
 		return runInteractiveAsync(ctx, session, rst)
 	}
@@ -66,73 +58,17 @@ func (session *JudgeSession) runSpecialJudge(rst *commonStructs.TestCaseResult)
 
 // 运行目标程序
 func runAsync(ctx context.Context, session *JudgeSession, rst *commonStructs.TestCaseResult, isChecker bool) (*ProcessInfo, error) {
-	var err error
-
-	runSuccess := make(chan bool, 1)
-	pid := 0
-	pinfo := ProcessInfo{}
-
-	go func() {
-		var pstate *cmd.ProcessState
-		var pArgs *PArgs
-		var proc *cmd.Process
-		// Get process options
-		pArgs, err = getProcessOptions(session, rst, isChecker, false, nil)
-		if err != nil {
-			runSuccess <- false
-			return
-		}
-		// Start process
-		proc, err = cmd.StartProcess(pArgs.Name, pArgs.Args, pArgs.Attr)
-		if err != nil {
-			runSuccess <- false
-			return
-		}
-		// Collect process info
-		pinfo.Process = proc
-		pinfo.Pid = proc.Pid
-		pid = proc.Pid
-		//log.Printf("Start process (%d)...\n", pinfo.Pid)
-		// Wait for exit.
-		pstate, err = proc.Wait()
-		if err != nil {
-			runSuccess <- false
-			return
-		}
-		//log.Printf("Process (%d) exited.\n", pinfo.Pid)
-		pinfo.Status = pstate.Sys().(syscall.WaitStatus)
-		pinfo.Rusage = pstate.SysUsage().(*syscall.Rusage)
-		if pinfo.Rusage == nil {
-			err = errors.Errorf("get rusage failed")
-			runSuccess <- false
-			return
-		}
-		closeFiles(pArgs.Attr.Files)
-		runSuccess <- true
-	}()
-	for {
-		select {
-		case <-runSuccess:
-			goto finish
-		case <-ctx.Done(): // 触发超时
-			log.Println("Child process timeout!")
-			err = errors.Errorf("Child process timeout!")
-			goto doClean
-		}
-	}
-doClean:
-	if pid > 0 {
-		_ = syscall.Kill(pid, syscall.SIGKILL)
-	}
-finish:
+	spec, err := getSandboxSpec(session, rst, isChecker, false, nil)
 	if err != nil {
 		return nil, err
 	}
-	return &pinfo, nil
+	defer closeFiles(spec.Files)
+	return runSandbox(ctx, spec)
 }
 
 // 运行交互评测
 func runInteractiveAsync(ctx context.Context, session *JudgeSession, rst *commonStructs.TestCaseResult) (*ProcessInfo, *ProcessInfo, error) {
+	var answer, checker *ProcessInfo
 	var answerErr, checkerErr, gErr error
 
 	fdChecker, err := forkexec.GetPipe()
@@ -145,143 +81,77 @@ func runInteractiveAsync(ctx context.Context, session *JudgeSession, rst *common
 		return nil, nil, errors.Errorf("create pipe error: %s", err.Error())
 	}
 
-	answer := ProcessInfo{}
-	checker := ProcessInfo{}
+	// 任何一方出错时，杀死另一方
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 	answerSuccess := make(chan bool, 1)
 	checkerSuccess := make(chan bool, 1)
-	answerPid := 0
-	checkerPid := 0
-	exitCounter := 0
 
 	go func() {
-		var pstate *cmd.ProcessState
-		var pArgs *PArgs
-		var proc *cmd.Process
-		// Get process options
-		pArgs, answerErr = getProcessOptions(session, rst, false, true, []uintptr{fdAnswer[0], fdChecker[1]})
-		if answerErr != nil {
-			answerSuccess <- false
-			return
-		}
-		// Start process
-		proc, answerErr = cmd.StartProcess(pArgs.Name, pArgs.Args, pArgs.Attr)
-		if answerErr != nil {
-			answerSuccess <- false
-			return
-		}
-		// Collect process info
-		answer.Process = proc
-		answer.Pid = proc.Pid
-		answerPid = proc.Pid
-		log.Printf("[Interactive]Start answer process (%d)...\n", answer.Pid)
-		// Wait for exit.
-		pstate, answerErr = answer.Process.Wait()
-		if answerErr != nil {
-			answerSuccess <- false
-			return
-		}
-		log.Printf("Process (%d) exited.\n", answer.Pid)
-		answer.Status = pstate.Sys().(syscall.WaitStatus)
-		answer.Rusage = pstate.SysUsage().(*syscall.Rusage)
-		if answer.Rusage == nil {
-			answerErr = errors.Errorf("get rusage failed")
+		spec, err := getSandboxSpec(session, rst, false, true, []uintptr{fdAnswer[0], fdChecker[1]})
+		if err != nil {
+			// 关闭管道，让判题程序读到EOF
+			closeFiles([]interface{}{fdAnswer[0], fdChecker[1]})
+			answerErr = err
 			answerSuccess <- false
 			return
 		}
-		closeFiles(pArgs.Attr.Files)
-		answerSuccess <- true
+		// 进程退出后关闭管道，对方才能读到EOF
+		defer closeFiles(spec.Files)
+		log.Println("[Interactive]Start answer process...")
+		answer, answerErr = runSandbox(ctx, spec)
+		answerSuccess <- answerErr == nil
 	}()
 
 	go func() {
-		var pstate *cmd.ProcessState
-		var pArgs *PArgs
-		var proc *cmd.Process
-		// Get process options
-		pArgs, checkerErr = getProcessOptions(session, rst, true, true, []uintptr{fdChecker[0], fdAnswer[1]})
-		if checkerErr != nil {
-			checkerSuccess <- false
-			return
-		}
-		// Start process
-		proc, checkerErr = cmd.StartProcess(pArgs.Name, pArgs.Args, pArgs.Attr)
-		if checkerErr != nil {
-			checkerSuccess <- false
-			return
-		}
-		// Collect process info
-		checker.Process = proc
-		checker.Pid = proc.Pid
-		checkerPid = proc.Pid
-		log.Printf("[Interactive]Start checker process (%d)...\n", checker.Pid)
-		// Wait for exit.
-		pstate, checkerErr = checker.Process.Wait()
-		if checkerErr != nil {
-			checkerSuccess <- false
-			return
-		}
-		log.Printf("Process (%d) exited.\n", checker.Pid)
-		checker.Status = pstate.Sys().(syscall.WaitStatus)
-		checker.Rusage = pstate.SysUsage().(*syscall.Rusage)
-		if checker.Rusage == nil {
-			checkerErr = errors.Errorf("get rusage failed")
+		spec, err := getSandboxSpec(session, rst, true, true, []uintptr{fdChecker[0], fdAnswer[1]})
+		if err != nil {
+			closeFiles([]interface{}{fdChecker[0], fdAnswer[1]})
+			checkerErr = err
 			checkerSuccess <- false
 			return
 		}
-		closeFiles(pArgs.Attr.Files)
-		checkerSuccess <- true
+		defer closeFiles(spec.Files)
+		log.Println("[Interactive]Start checker process...")
+		checker, checkerErr = runSandbox(ctx, spec)
+		checkerSuccess <- checkerErr == nil
 	}()
-	for {
+
+	for exitCounter := 0; exitCounter < 2; exitCounter++ {
 		select {
-		case _ = <-answerSuccess:
-			exitCounter++
-			if answerErr != nil {
+		case ok := <-answerSuccess:
+			if !ok && gErr == nil {
 				gErr = answerErr
-				goto doClean
+				cancel()
 			}
-			if exitCounter >= 2 {
-				goto finish
-			}
-		case _ = <-checkerSuccess:
-			exitCounter++
-			if checkerErr != nil {
+		case ok := <-checkerSuccess:
+			if !ok && gErr == nil {
 				gErr = checkerErr
-				goto doClean
-			}
-			if exitCounter >= 2 {
-				goto finish
+				cancel()
 			}
-		case <-ctx.Done(): // 触发超时
-			log.Println("Child process timeout!")
-			gErr = errors.Errorf("Child process timeout!")
-			goto doClean
 		}
 	}
-doClean:
-	if answerPid > 0 {
-		_ = syscall.Kill(answerPid, syscall.SIGKILL)
-	}
-	if checkerPid > 0 {
-		_ = syscall.Kill(checkerPid, syscall.SIGKILL)
-	}
-finish:
 	if gErr != nil {
 		return nil, nil, gErr
 	}
-	return &answer, &checker, nil
+	return answer, checker, nil
 }
 
-// 运行一个新的进程
-func getProcessOptions(session *JudgeSession, rst *commonStructs.TestCaseResult, isChecker, pipeMode bool, pipeFd []uintptr) (*PArgs, error) {
-	var err error
-	// Get shell commands
-	commands := session.Commands
-	// 参考exec.Command，从环境变量获取编译器/VM真实的地址
-	programPath := commands[0]
-	if filepath.Base(programPath) == programPath {
-		if programPath, err = exec.LookPath(programPath); err != nil {
-			return nil, err
+// 在沙箱里运行进程
+func runSandbox(ctx context.Context, spec *sandbox.Spec) (*ProcessInfo, error) {
+	usage, err := sandbox.Run(ctx, *spec)
+	if err != nil {
+		if err == context.DeadlineExceeded {
+			log.Println("Child process timeout!")
+			return nil, errors.Errorf("Child process timeout!")
 		}
+		return nil, err
 	}
+	return usage, nil
+}
+
+// 生成沙箱的运行参数
+func getSandboxSpec(session *JudgeSession, rst *commonStructs.TestCaseResult, isChecker, pipeMode bool, pipeFd []uintptr) (*sandbox.Spec, error) {
 	var infile, outfile, errfile string
 	var rlimit forkexec.ExecRLimit
 	var args []string
@@ -306,16 +176,21 @@ func getProcessOptions(session *JudgeSession, rst *commonStructs.TestCaseResult,
 		}
 		args = getSpecialJudgeArgs(session, rst)
 	} else {
-		execProgram = programPath
+		// 参考exec.Command，从环境变量获取编译器/VM真实的地址
+		execProgram = session.Commands[0]
 		outfile = path.Join(session.SessionDir, rst.ProgramOut)
 		errfile = path.Join(session.SessionDir, rst.ProgramError)
 		rlimit = forkexec.ExecRLimit{
-			TimeLimit:     session.JudgeConfig.TimeLimit,
-			MemoryLimit:   session.JudgeConfig.MemoryLimit,
-			RealTimeLimit: session.JudgeConfig.RealTimeLimit,
-			FileSizeLimit: session.JudgeConfig.FileSizeLimit,
+			TimeLimit:      session.JudgeConfig.TimeLimit,
+			MemoryLimit:    session.JudgeConfig.MemoryLimit,
+			RealTimeLimit:  session.JudgeConfig.RealTimeLimit,
+			FileSizeLimit:  session.JudgeConfig.FileSizeLimit,
+			ProcessLimit:   session.JudgeConfig.ProcessLimit,
+			StackLimit:     session.JudgeConfig.StackLimit,
+			OpenFilesLimit: session.JudgeConfig.OpenFilesLimit,
+			CoreDump:       session.JudgeConfig.CoreDump,
 		}
-		args = commands
+		args = session.Commands
 	}
 	if pipeMode {
 		// Open err file
@@ -333,27 +208,105 @@ func getProcessOptions(session *JudgeSession, rst *commonStructs.TestCaseResult,
 		// Open out file
 		stdout, err := os.OpenFile(outfile, os.O_WRONLY|os.O_CREATE, 0644)
 		if err != nil {
+			_ = stdin.Close()
 			return nil, err
 		}
 		// Open err file
 		stderr, err := os.OpenFile(errfile, os.O_WRONLY|os.O_CREATE, 0644)
 		if err != nil {
+			_ = stdin.Close()
+			_ = stdout.Close()
 			return nil, err
 		}
 		files = []interface{}{stdin, stdout, stderr}
 	}
-	return &PArgs{
-		Name: execProgram,
-		Args: args,
-		Attr: &cmd.ProcAttr{
-			Dir:   session.SessionDir,
-			Env:   append(os.Environ(), ExtraEnviron...),
-			Files: files,
-			Sys: &forkexec.SysProcAttr{
-				Rlimit: rlimit,
-			},
-		},
-	}, nil
+	spec := sandbox.Spec{
+		Path:   execProgram,
+		Args:   args,
+		Dir:    session.SessionDir,
+		Env:    os.Environ(),
+		Files:  files,
+		Limits: rlimit,
+	}
+	spec.UID, spec.GID = session.credential()
+	spec.MemoryStrategy = sandbox.MemoryStrategy(session.MemoryStrategy)
+	if session.CPUCore >= 0 {
+		spec.CPUs = []int{session.CPUCore}
+	}
+	if !isChecker {
+		// 目标程序不继承评测机的环境变量，避免泄露评测机上的敏感信息
+		spec.Env = buildEnviron(session.getEnvironmentPolicy(), rst.Handle)
+		if err := applyTargetOptions(session, &spec); err != nil {
+			closeFiles(files)
+			return nil, err
+		}
+	}
+	if session.CgroupRoot != "" {
+		role := "program"
+		if isChecker {
+			role = "checker"
+		}
+		spec.Cgroup = &sandbox.CgroupOptions{
+			Root:      session.CgroupRoot,
+			Name:      fmt.Sprintf("%s_%s_%s", session.SessionID, rst.Handle, role),
+			PidsLimit: cgroup.DefaultPidsLimit,
+			CPULimit:  100,
+		}
+		if rlimit.ProcessLimit > 0 {
+			spec.Cgroup.PidsLimit = rlimit.ProcessLimit
+		} else if rlimit.ProcessLimit < 0 {
+			spec.Cgroup.PidsLimit = 0
+		}
+		if session.SessionID == "" {
+			spec.Cgroup.Name = ""
+		}
+	}
+	return &spec, nil
+}
+
+// 目标程序专用的设置：命名空间隔离、系统调用过滤、CPU时间监控、空闲检测和资源使用时间线
+func applyTargetOptions(session *JudgeSession, spec *sandbox.Spec) error {
+	if session.Compiler != nil {
+		opts := session.Isolation
+		lang := opts.Languages[session.Compiler.GetName()]
+		if opts.Enabled && !lang.Disabled {
+			mounts := make([]sandbox.Mount, 0, len(opts.Rootfs)+len(lang.Rootfs)+1)
+			for _, p := range opts.Rootfs {
+				mounts = append(mounts, sandbox.Mount{Source: p})
+			}
+			for _, p := range lang.Rootfs {
+				mounts = append(mounts, sandbox.Mount{Source: p})
+			}
+			// 目标程序和代码都在会话目录里，挂载到相同的路径下
+			mounts = append(mounts, sandbox.Mount{Source: session.SessionDir, Writable: true})
+			spec.Isolation = &sandbox.Isolation{Mounts: mounts, UID: opts.UID, GID: opts.GID}
+			if spec.UID > 0 {
+				// 优先使用会话租用的用户
+				spec.Isolation.UID, spec.Isolation.GID = 0, 0
+			}
+		}
+		if session.Seccomp {
+			profile, err := seccomp.GetProfile(session.Compiler.GetSeccompProfile())
+			if err != nil {
+				return err
+			}
+			spec.Seccomp = profile
+		}
+	}
+	if session.CPUSupervisorInterval > 0 {
+		spec.SupervisorInterval = session.CPUSupervisorInterval
+	}
+	spec.IdlenessLimit = session.IdlenessLimit
+	if spec.IdlenessLimit == 0 && spec.Limits.RealTimeLimit <= 0 {
+		// 设置了真实时间限制的题目，阻塞的程序会因为超出真实时间限制被判为TLE
+		spec.IdlenessLimit = constants.IdlenessLimit
+	}
+	if spec.IdlenessLimit < 0 {
+		spec.IdlenessLimit = 0
+	}
+	spec.TimelineInterval = session.TimelineInterval
+	spec.ReportPeakTasks = true
+	return nil
 }
 
 // 构建判题程序的命令行参数
@@ -3,6 +3,7 @@ package executor
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/LanceLRQ/deer-executor/v2/common/constants"
 	"github.com/LanceLRQ/deer-executor/v2/common/logger"
 	"github.com/LanceLRQ/deer-executor/v2/common/provider"
 	commonStructs "github.com/LanceLRQ/deer-executor/v2/common/structs"
@@ -16,16 +17,30 @@ import (
 
 // JudgeSession 评测会话类
 type JudgeSession struct {
-	SessionID    string   // Judge Session Id
-	SessionRoot  string   // Session Root Directory
-	SessionDir   string   // Session Directory
-	ConfigFile   string   // Config file
-	ConfigDir    string   // Config file dir
-	CodeLangName string   // Code file language name
-	CodeFile     string   // Code File Path
-	CodeStr      string   // Code Str (if set, use it first)
-	LibraryDir   string   // Compile Library Path for Working Program
-	Commands     []string // Executable program commands
+	SessionID    string    // Judge Session Id
+	SessionRoot  string    // Session Root Directory
+	SessionDir   string    // Session Directory
+	ConfigFile   string    // Config file
+	ConfigDir    string    // Config file dir
+	CodeLangName string    // Code file language name
+	CodeFile     string    // Code File Path (output archive or directory for output-only problems)
+	CodeStr      string    // Code Str (if set, use it first)
+	LibraryDir   string    // Compile Library Path for Working Program
+	Commands     []string  // Executable program commands
+	CgroupRoot   string    // cgroup v2 root for judged processes (optional, empty means disabled)
+	Seccomp      bool      // Enable seccomp syscall filter for the target program
+	UID          int       // Run the target program and checker as this user, 0 means JudgeConfig.UID or the judge's own user
+	GID          int       // Group of UID, 0 means the same as UID
+	CPUCore      int       // Pin the target program and checker to this CPU core, -1 means not pinned
+	Parallelism  int       // Number of test cases running at the same time, 0 or 1 means one by one (always one by one when CPUCore is set)
+	CasePool     *CasePool // Limit of test cases running at the same time shared by sessions (optional)
+
+	CPUSupervisorInterval int    // Polling interval of the CPU time supervisor (ms), 0 means disabled
+	IdlenessLimit         int    // Kill the program if its CPU time stays flat for this long (ms), 0 means default (only if no real time limit), negative means disabled
+	TimelineInterval      int    // Sampling interval of the resource usage timeline of the target program (ms), 0 means disabled
+	MemoryStrategy        string // Peak memory accounting: minflt, maxrss, vmhwm or cgroup_peak, empty means cgroup_peak if cgroup is enabled, otherwise minflt
+
+	Isolation commonStructs.IsolationOptions // Namespace isolation options for the target program
 
 	JudgeConfig commonStructs.JudgeConfiguration      // Judge Configurations
 	Compiler    provider.CodeCompileProviderInterface // Compiler entity
@@ -62,8 +77,10 @@ func NewSession(configFile string) (*JudgeSession, error) {
 	session := JudgeSession{}
 	session.Logger = logger.NewJudgeLogger()
 	session.Timeout = 30 // 默认30秒超时
+	session.CPUSupervisorInterval = constants.CPUSupervisorInterval
 	session.SessionRoot = "/tmp"
 	session.CodeLangName = "auto"
+	session.CPUCore = -1
 	session.JudgeConfig.UID = -1
 	session.JudgeConfig.TimeLimit = 1000
 	session.JudgeConfig.MemoryLimit = 65535
@@ -3,15 +3,7 @@
 
 package executor
 
-import (
-	"github.com/LanceLRQ/deer-executor/v2/common/sandbox/cmd"
-	"syscall"
-)
+import "github.com/LanceLRQ/deer-executor/v2/common/sandbox"
 
-// ProcessInfo 进程信息
-type ProcessInfo struct {
-	Pid     int                `json:"pid"`
-	Process *cmd.Process       `json:"-"`
-	Status  syscall.WaitStatus `json:"status"`
-	Rusage  *syscall.Rusage    `json:"rusage"`
-}
+// ProcessInfo 进程信息（即沙箱返回的资源使用情况）
+type ProcessInfo = sandbox.Usage
@@ -0,0 +1,202 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package executor
+
+import (
+	"context"
+	"github.com/LanceLRQ/deer-executor/v2/common/constants"
+	commonStructs "github.com/LanceLRQ/deer-executor/v2/common/structs"
+	"github.com/pkg/errors"
+	"math"
+)
+
+// 检查子任务的设置，并按依赖关系排序（没有依赖关系的子任务保持配置里的顺序），返回子任务的下标
+func (session *JudgeSession) sortSubtasks() ([]int, error) {
+	subtasks := session.JudgeConfig.Subtasks
+	handles := map[string]bool{}
+	for _, tc := range session.JudgeConfig.TestCases {
+		handles[tc.Handle] = true
+	}
+	index := map[string]int{}
+	for i, st := range subtasks {
+		if st.Name == "" {
+			return nil, errors.Errorf("subtask #%d has no name", i)
+		}
+		if _, ok := index[st.Name]; ok {
+			return nil, errors.Errorf("duplicate subtask: %s", st.Name)
+		}
+		index[st.Name] = i
+		switch st.Policy {
+		case "", constants.SubtaskPolicyAll, constants.SubtaskPolicyMin, constants.SubtaskPolicySum:
+		default:
+			return nil, errors.Errorf("subtask %s: unknown scoring policy: %s", st.Name, st.Policy)
+		}
+		if len(st.Cases) == 0 {
+			return nil, errors.Errorf("subtask %s has no test cases", st.Name)
+		}
+		for _, h := range st.Cases {
+			if !handles[h] {
+				return nil, errors.Errorf("subtask %s: test case (%s) not exists", st.Name, h)
+			}
+		}
+	}
+	for _, st := range subtasks {
+		for _, dep := range st.DependsOn {
+			if _, ok := index[dep]; !ok {
+				return nil, errors.Errorf("subtask %s: dependency (%s) not exists", st.Name, dep)
+			}
+		}
+	}
+	// 每一轮按配置的顺序取出依赖都已经排好的子任务
+	order := make([]int, 0, len(subtasks))
+	sorted := make([]bool, len(subtasks))
+	for len(order) < len(subtasks) {
+		progress := false
+		for i, st := range subtasks {
+			if sorted[i] {
+				continue
+			}
+			ready := true
+			for _, dep := range st.DependsOn {
+				if !sorted[index[dep]] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				sorted[i] = true
+				order = append(order, i)
+				progress = true
+			}
+		}
+		if !progress {
+			return nil, errors.Errorf("circular dependency between subtasks")
+		}
+	}
+	return order, nil
+}
+
+// 按依赖关系依次评测每个子任务，返回按运行顺序排列的测试数据结果、子任务结果（按配置的顺序）以及评测过的测试数据数量
+// 同一组测试数据被多个子任务引用时只运行一次；all和min策略的子任务在某组测试数据失败后跳过剩下的测试数据，依赖的子任务没有通过时跳过整个子任务
+func (session *JudgeSession) runSubtasks(ctx context.Context, order []int) ([]*commonStructs.TestCaseResult, []commonStructs.SubtaskResult, int) {
+	subtasks := session.JudgeConfig.Subtasks
+	cases := map[string]commonStructs.TestCase{}
+	for _, tc := range session.JudgeConfig.TestCases {
+		cases[tc.Handle] = tc
+	}
+	judged := map[string]*commonStructs.TestCaseResult{}
+	runOrder := make([]*commonStructs.TestCaseResult, 0, len(cases))
+	results := make([]commonStructs.SubtaskResult, len(subtasks))
+	required := map[string]bool{}
+	passed := map[string]bool{}
+
+	for _, i := range order {
+		st := subtasks[i]
+		sr := commonStructs.SubtaskResult{
+			Name:     st.Name,
+			MaxScore: st.Score,
+			Policy:   st.Policy,
+			Cases:    []string{},
+			Skipped:  []string{},
+		}
+		if sr.Policy == "" {
+			sr.Policy = constants.SubtaskPolicyAll
+		}
+		for _, h := range st.Cases {
+			required[h] = true
+		}
+		for _, dep := range st.DependsOn {
+			if !passed[dep] {
+				sr.Dependency = dep
+				break
+			}
+		}
+		if sr.Dependency != "" {
+			for _, r := range results {
+				if r.Name == sr.Dependency {
+					sr.JudgeResult = r.JudgeResult
+				}
+			}
+			sr.Skipped = append(sr.Skipped, st.Cases...)
+			session.Logger.Infof("Skip subtask %s: dependency %s not passed", st.Name, sr.Dependency)
+			results[i] = sr
+			continue
+		}
+
+		session.Logger.Infof("Run subtask: %s", st.Name)
+		skippable := sr.Policy != constants.SubtaskPolicySum
+		failed := func(rst *commonStructs.TestCaseResult) bool {
+			return skippable && rst.Score < 1
+		}
+		// 先看已经评测过的测试数据，已经有失败的就不用再运行其他的了
+		pending := make([]commonStructs.TestCase, 0, len(st.Cases))
+		stop := false
+		for _, h := range st.Cases {
+			if rst, ok := judged[h]; ok {
+				stop = stop || failed(rst)
+			} else {
+				pending = append(pending, cases[h])
+			}
+		}
+		if !stop {
+			for _, rst := range session.runTestCases(ctx, pending, caseStopper{isFinal: failed}) {
+				if rst == nil {
+					break
+				}
+				judged[rst.Handle] = rst
+				runOrder = append(runOrder, rst)
+			}
+		}
+
+		scores := make([]float64, 0, len(st.Cases))
+		sr.JudgeResult = constants.JudgeFlagAC
+		for _, h := range st.Cases {
+			rst, ok := judged[h]
+			if !ok {
+				sr.Skipped = append(sr.Skipped, h)
+				scores = append(scores, 0)
+				continue
+			}
+			sr.Cases = append(sr.Cases, h)
+			score := rst.Score
+			if score < 1 && sr.JudgeResult == constants.JudgeFlagAC {
+				sr.JudgeResult = rst.JudgeResult
+			}
+			scores = append(scores, score)
+		}
+		sr.Score = st.Score * subtaskScore(sr.Policy, scores)
+		passed[st.Name] = sr.JudgeResult == constants.JudgeFlagAC && len(sr.Skipped) == 0
+		session.Logger.Infof("Subtask %s: %g / %g", st.Name, sr.Score, sr.MaxScore)
+		results[i] = sr
+	}
+	return runOrder, results, len(required)
+}
+
+// 按策略计算子任务的得分比例
+func subtaskScore(policy string, scores []float64) float64 {
+	if len(scores) == 0 {
+		return 0
+	}
+	switch policy {
+	case constants.SubtaskPolicyMin:
+		min := math.Inf(1)
+		for _, s := range scores {
+			min = math.Min(min, s)
+		}
+		return min
+	case constants.SubtaskPolicySum:
+		sum := 0.0
+		for _, s := range scores {
+			sum += s
+		}
+		return sum / float64(len(scores))
+	default:
+		for _, s := range scores {
+			if s < 1 {
+				return 0
+			}
+		}
+		return 1
+	}
+}
@@ -0,0 +1,44 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package executor
+
+import (
+	commonStructs "github.com/LanceLRQ/deer-executor/v2/common/structs"
+	"reflect"
+	"testing"
+)
+
+// Test: check and sort subtasks by their dependencies
+// 循环依赖和不存在的测试数据已经由TestAPlusBProblemSubtasks覆盖
+func TestSortSubtasks(t *testing.T) {
+	cases := []struct {
+		subtasks []commonStructs.Subtask
+		order    []int
+		err      string
+	}{
+		// 依赖排在后面的子任务先运行，没有依赖关系的保持配置里的顺序
+		{[]commonStructs.Subtask{
+			{Name: "c", Cases: []string{"3"}, DependsOn: []string{"a", "b"}},
+			{Name: "b", Cases: []string{"2"}, DependsOn: []string{"a"}},
+			{Name: "a", Cases: []string{"1"}},
+			{Name: "d", Cases: []string{"1"}},
+		}, []int{2, 3, 1, 0}, ""},
+		{[]commonStructs.Subtask{{Name: "a", Cases: []string{"1"}, DependsOn: []string{"x"}}}, nil, "subtask a: dependency (x) not exists"},
+		{[]commonStructs.Subtask{{Name: "a", Cases: []string{"1"}}, {Name: "a", Cases: []string{"2"}}}, nil, "duplicate subtask: a"},
+		{[]commonStructs.Subtask{{Cases: []string{"1"}}}, nil, "subtask #0 has no name"},
+		{[]commonStructs.Subtask{{Name: "a"}}, nil, "subtask a has no test cases"},
+		{[]commonStructs.Subtask{{Name: "a", Cases: []string{"1"}, Policy: "max"}}, nil, "subtask a: unknown scoring policy: max"},
+	}
+	for i, c := range cases {
+		session := &JudgeSession{}
+		session.JudgeConfig.TestCases = []commonStructs.TestCase{{Handle: "1"}, {Handle: "2"}, {Handle: "3"}}
+		session.JudgeConfig.Subtasks = c.subtasks
+		order, err := session.sortSubtasks()
+		if (err == nil && c.err != "") || (err != nil && err.Error() != c.err) || !reflect.DeepEqual(order, c.order) {
+			t.Fatalf("case #%d: expect %v (%q), got %v (%v)", i, c.order, c.err, order, err)
+			return
+		}
+	}
+	t.Log("OK")
+}
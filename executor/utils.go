@@ -1,14 +1,12 @@
 package executor
 
 import (
-	"fmt"
+	"context"
 	"github.com/LanceLRQ/deer-executor/v2/common/provider"
 	commonStructs "github.com/LanceLRQ/deer-executor/v2/common/structs"
 	"github.com/pkg/errors"
-	"io/ioutil"
 	"os"
 	"path"
-	"syscall"
 )
 
 // Max find max between x & y
@@ -27,35 +25,11 @@ func Max32(a, b int) int {
 	return b
 }
 
-// 文件读写(有重试次数，checker专用)
-func readFileWithTry(filePath string, name string, tryOnFailed int) ([]byte, string, error) {
-	errCnt, errText := 0, ""
-	var err error
-	for errCnt < tryOnFailed {
-		fp, err := os.OpenFile(filePath, os.O_RDONLY|syscall.O_NONBLOCK, 0)
-		if err != nil {
-			errText = err.Error()
-			errCnt++
-			continue
-		}
-		data, err := ioutil.ReadAll(fp)
-		if err != nil {
-			_ = fp.Close()
-			errText = fmt.Sprintf("Read file(%s) i/o error: %s", name, err.Error())
-			errCnt++
-			continue
-		}
-		_ = fp.Close()
-		return data, errText, nil
-	}
-	return nil, errText, err
-}
-
 // CheckRequireFilesExists 检查配置文件里的所有文件是否存在
 func CheckRequireFilesExists(config *commonStructs.JudgeConfiguration, configDir string) error {
 	var err error
 	// 检查特判程序是否存在
-	if config.SpecialJudge.Mode != 0 {
+	if config.SpecialJudge.Mode != 0 && config.SpecialJudge.Builtin == "" {
 		_, err = os.Stat(path.Join(configDir, config.SpecialJudge.Checker))
 		if os.IsNotExist(err) {
 			return errors.Errorf("special judge checker file (%s) not exists", config.SpecialJudge.Checker)
@@ -95,7 +69,7 @@ func GetOrCreateBinaryRoot(config *commonStructs.JudgeConfiguration) (string, er
 }
 
 // CompileSpecialJudgeCodeFile 普通特殊评测的编译方法
-func CompileSpecialJudgeCodeFile(source, name, binRoot, configDir, libraryDir, lang string) (string, error) {
+func CompileSpecialJudgeCodeFile(ctx context.Context, source, name, binRoot, configDir, libraryDir, lang string) (string, error) {
 	genCodeFile := path.Join(configDir, source)
 	compileTarget := path.Join(binRoot, name)
 	_, err := os.Stat(genCodeFile)
@@ -107,13 +81,13 @@ func CompileSpecialJudgeCodeFile(source, name, binRoot, configDir, libraryDir, l
 	switch lang {
 	case "c", "gcc", "gnu-c":
 		compiler := provider.NewGnucppCompileProvider()
-		ok, ceinfo = compiler.ManualCompile(genCodeFile, compileTarget, []string{libraryDir})
+		ok, ceinfo = compiler.ManualCompile(ctx, genCodeFile, compileTarget, []string{libraryDir})
 	case "go", "golang":
 		compiler := provider.NewGolangCompileProvider()
-		ok, ceinfo = compiler.ManualCompile(genCodeFile, compileTarget)
+		ok, ceinfo = compiler.ManualCompile(ctx, genCodeFile, compileTarget)
 	case "cpp", "gcc-cpp", "gcpp", "g++", "":
 		compiler := provider.NewGnucppCompileProvider()
-		ok, ceinfo = compiler.ManualCompile(genCodeFile, compileTarget, []string{libraryDir})
+		ok, ceinfo = compiler.ManualCompile(ctx, genCodeFile, compileTarget, []string{libraryDir})
 	default:
 		return compileTarget, errors.Errorf("checker must be written by c/c++/golang")
 	}
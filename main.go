@@ -7,6 +7,7 @@ import (
 	"github.com/LanceLRQ/deer-executor/v2/client"
 	"github.com/LanceLRQ/deer-executor/v2/client/generate"
 	"github.com/LanceLRQ/deer-executor/v2/client/run"
+	"github.com/LanceLRQ/deer-executor/v2/client/sandbox"
 	"github.com/gookit/config/v2"
 	"github.com/gookit/config/v2/yamlv3"
 	"github.com/urfave/cli/v2"
@@ -74,6 +75,20 @@ func main() {
 				Usage:       "problem manager",
 				Subcommands: client.AppProblemSubCommands,
 			},
+			{
+				Name:  "sandbox",
+				Usage: "sandbox tools",
+				Subcommands: cli.Commands{
+					{
+						Name:      "exec",
+						HelpName:  "deer-executor sandbox exec",
+						Usage:     "run a command under limits and print its resource usage",
+						ArgsUsage: "[--] <command> [args...]",
+						Flags:     client.SandboxExecFlags,
+						Action:    sandbox.Exec,
+					},
+				},
+			},
 			{
 				Name:   "agent",
 				Hidden: true,
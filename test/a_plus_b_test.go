@@ -1,9 +1,17 @@
 package test
 
 import (
+	"context"
 	"github.com/LanceLRQ/deer-executor/v2/common/constants"
+	commonStructs "github.com/LanceLRQ/deer-executor/v2/common/structs"
+	"github.com/LanceLRQ/deer-executor/v2/executor"
+	"io/ioutil"
+	"math"
+	"os"
 	"runtime"
+	"strings"
 	"testing"
+	"time"
 )
 
 // Test: AC
@@ -289,3 +297,1260 @@ func TestAPlusBProblemWA2(t *testing.T) {
 	}
 	t.Log("OK")
 }
+
+// Test: AC with seccomp filter
+func TestAPlusBProblemSeccompAc(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Log("Seccomp: Skip")
+		return
+	}
+	err := initWorkRoot()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	result, err := runJudgeWith(aPlusBProblem, "./data/codes/APlusB/ac.c", "", func(session *executor.JudgeSession) {
+		session.Seccomp = true
+	})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	err = analysisResult("seccomp ac", result, constants.JudgeFlagAC)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	t.Log("OK")
+}
+
+// Test: RF which open a socket
+func TestAPlusBProblemRF(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Log("Seccomp: Skip")
+		return
+	}
+	err := initWorkRoot()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	result, err := runJudgeWith(aPlusBProblem, "./data/codes/APlusB/rf.c", "", func(session *executor.JudgeSession) {
+		session.Seccomp = true
+	})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	err = analysisResult("seccomp rf", result, constants.JudgeFlagRF)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if result.TestCases[0].ReInfo != "restricted function: socket" {
+		t.Fatalf("unexpected re info: %s", result.TestCases[0].ReInfo)
+	}
+	t.Log("OK")
+}
+
+// Test: RF which fork a new process
+func TestAPlusBProblemRF2(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Log("Seccomp: Skip")
+		return
+	}
+	err := initWorkRoot()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	result, err := runJudgeWith(aPlusBProblem, "./data/codes/APlusB/rf2.c", "", func(session *executor.JudgeSession) {
+		session.Seccomp = true
+	})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	err = analysisResult("seccomp rf2", result, constants.JudgeFlagRF)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	t.Log("OK")
+}
+
+// Test: AC with namespace isolation
+func TestAPlusBProblemIsolationAc(t *testing.T) {
+	if runtime.GOOS != "linux" || os.Geteuid() != 0 {
+		t.Log("Isolation: Skip")
+		return
+	}
+	err := initWorkRoot()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	result, err := runJudgeWith(aPlusBProblem, "./data/codes/APlusB/ac.c", "", useIsolation)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	err = analysisResult("isolation ac", result, constants.JudgeFlagAC)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	t.Log("OK")
+}
+
+// Test: TLE with namespace isolation
+func TestAPlusBProblemIsolationTLE(t *testing.T) {
+	if runtime.GOOS != "linux" || os.Geteuid() != 0 {
+		t.Log("Isolation: Skip")
+		return
+	}
+	err := initWorkRoot()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	result, err := runJudgeWith(aPlusBProblem, "./data/codes/APlusB/tle.c", "", useIsolation)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	err = analysisResult("isolation tle", result, constants.JudgeFlagTLE)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	t.Log("OK")
+}
+
+// Test: OLE with namespace isolation
+func TestAPlusBProblemIsolationOLE(t *testing.T) {
+	if runtime.GOOS != "linux" || os.Geteuid() != 0 {
+		t.Log("Isolation: Skip")
+		return
+	}
+	err := initWorkRoot()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	result, err := runJudgeWith(aPlusBProblem, "./data/codes/APlusB/ole.c", "", useIsolation)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	err = analysisResult("isolation ole", result, constants.JudgeFlagOLE)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	t.Log("OK")
+}
+
+// Test: RE with namespace isolation
+func TestAPlusBProblemIsolationRE(t *testing.T) {
+	if runtime.GOOS != "linux" || os.Geteuid() != 0 {
+		t.Log("Isolation: Skip")
+		return
+	}
+	err := initWorkRoot()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	result, err := runJudgeWith(aPlusBProblem, "./data/codes/APlusB/re.c", "", useIsolation)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	err = analysisResult("isolation re", result, constants.JudgeFlagRE)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	t.Log("OK")
+}
+
+// Test: AC which leaves forked processes behind
+func TestAPlusBProblemStrayProcesses(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Log("Stray processes: Skip")
+		return
+	}
+	err := initWorkRoot()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	result, err := runAPlusB("./data/codes/APlusB/stray.c", "")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	err = analysisResult("stray", result, constants.JudgeFlagAC)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	for _, tc := range result.TestCases {
+		if tc.StrayProcesses != 2 {
+			t.Fatalf("[%s] expect 2 stray processes, got %d", tc.Handle, tc.StrayProcesses)
+		}
+	}
+	t.Log("OK")
+}
+
+// Test: TLE caught by the CPU time supervisor at the exact millisecond limit
+func TestAPlusBProblemTLESupervisor(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Log("CPU supervisor: Skip")
+		return
+	}
+	err := initWorkRoot()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	result, err := runJudgeWith(aPlusBProblem, "./data/codes/APlusB/tle.c", "", func(session *executor.JudgeSession) {
+		session.JudgeConfig.TimeLimit = 1500
+		session.CPUSupervisorInterval = 10
+	})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	err = analysisResult("supervisor tle", result, constants.JudgeFlagTLE)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	for _, tc := range result.TestCases {
+		if tc.TimeLimitKiller != constants.TimeLimitKillerSupervisor {
+			t.Fatalf("[%s] expect killed by supervisor, got %s", tc.Handle, tc.TimeLimitKiller)
+		}
+		// RLIMIT_CPU would let the program run for 2 seconds
+		if tc.SupervisorTimeUsed <= 1500 || tc.TimeUsed >= 1900 {
+			t.Fatalf("[%s] unexpected time used: %d (supervisor: %d)", tc.Handle, tc.TimeUsed, tc.SupervisorTimeUsed)
+		}
+	}
+	t.Log("OK")
+}
+
+// Test: TLE caught by RLIMIT_CPU when the supervisor is disabled
+func TestAPlusBProblemTLERLimit(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Log("CPU supervisor: Skip")
+		return
+	}
+	err := initWorkRoot()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	result, err := runJudgeWith(aPlusBProblem, "./data/codes/APlusB/tle.c", "", func(session *executor.JudgeSession) {
+		session.JudgeConfig.TimeLimit = 1500
+		session.CPUSupervisorInterval = -1
+	})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	err = analysisResult("rlimit tle", result, constants.JudgeFlagTLE)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	for _, tc := range result.TestCases {
+		if tc.TimeLimitKiller != constants.TimeLimitKillerRLimit {
+			t.Fatalf("[%s] expect killed by rlimit, got %s", tc.Handle, tc.TimeLimitKiller)
+		}
+	}
+	t.Log("OK")
+}
+
+// Test: ILE which waits forever without using any cpu time
+func TestAPlusBProblemILE(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Log("Idleness limit: Skip")
+		return
+	}
+	err := initWorkRoot()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	result, err := runJudgeWith(aPlusBProblem, "./data/codes/APlusB/ile.c", "", func(session *executor.JudgeSession) {
+		session.IdlenessLimit = 500
+	})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	err = analysisResult("ile", result, constants.JudgeFlagILE)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	t.Log("OK")
+}
+
+// Test: ILE which sleeps forever when the problem has no real time limit
+func TestAPlusBProblemILE2(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Log("Idleness limit: Skip")
+		return
+	}
+	err := initWorkRoot()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	result, err := runJudgeWith(aPlusBProblem, "./data/codes/APlusB/tle2.c", "", func(session *executor.JudgeSession) {
+		session.JudgeConfig.RealTimeLimit = 0
+	})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	err = analysisResult("ile2", result, constants.JudgeFlagILE)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	t.Log("OK")
+}
+
+// Test: Program runs in an empty environment with ONLINE_JUDGE and RANDOM_SEED
+func TestAPlusBProblemEnviron(t *testing.T) {
+	err := initWorkRoot()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	result, err := runAPlusB("./data/codes/APlusB/env.c", "")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	err = analysisResult("environ", result, constants.JudgeFlagAC)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	t.Log("OK")
+}
+
+// Test: Whitelisted variables are inherited from the judge
+func TestAPlusBProblemEnvironInherit(t *testing.T) {
+	if os.Getenv("HOME") == "" {
+		t.Log("HOME is not set: Skip")
+		return
+	}
+	err := initWorkRoot()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	result, err := runJudgeWith(aPlusBProblem, "./data/codes/APlusB/env.c", "", func(session *executor.JudgeSession) {
+		session.JudgeConfig.Environment.Inherit = []string{"HOME"}
+	})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	err = analysisResult("environ inherit", result, constants.JudgeFlagWA)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	t.Log("OK")
+}
+
+// Test: Program runs as an unprivileged user and owns the session dir
+func TestAPlusBProblemUser(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Log("Run as user: Skip (requires root)")
+		return
+	}
+	err := initWorkRoot()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	result, err := runJudgeWith(aPlusBProblem, "./data/codes/APlusB/uid.c", "", func(session *executor.JudgeSession) {
+		session.UID = 65534
+		session.GID = 65534
+	})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	err = analysisResult("user", result, constants.JudgeFlagAC)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	t.Log("OK")
+}
+
+// Test: Process/thread limit exceeded
+func TestAPlusBProblemProcessLimit(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Log("Process limit: Skip")
+		return
+	}
+	err := initWorkRoot()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	result, err := runAPlusB("./data/codes/APlusB/fork.c", "")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	err = analysisResult("process limit", result, constants.JudgeFlagRE)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	tc := result.TestCases[0]
+	if tc.PeakTasks <= constants.ProcessLimitForLanguage["gcc"] || !strings.Contains(tc.ReInfo, "process/thread limit exceeded") {
+		t.Fatalf("unexpected peak tasks (%d) or re info (%s)", tc.PeakTasks, tc.ReInfo)
+	}
+	t.Log("OK")
+}
+
+// Test: Stack limit defaults to twice the memory limit, and can be set explicitly
+func TestAPlusBProblemStackLimit(t *testing.T) {
+	err := initWorkRoot()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	result, err := runAPlusB("./data/codes/APlusB/stack.c", "")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	err = analysisResult("stack default", result, constants.JudgeFlagAC)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if runtime.GOOS == "darwin" {
+		t.Log("Stack limit: Skip")
+		return
+	}
+	result, err = runJudgeWith(aPlusBProblem, "./data/codes/APlusB/stack.c", "", func(session *executor.JudgeSession) {
+		session.JudgeConfig.Limitation = map[string]commonStructs.JudgeResourceLimit{
+			"gcc": {TimeLimit: 1000, MemoryLimit: 32768, StackLimit: 1024},
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	err = analysisResult("stack 1MB", result, constants.JudgeFlagRE)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	t.Log("OK")
+}
+
+// Test: Open files limit and core dump
+func TestAPlusBProblemOpenFilesLimit(t *testing.T) {
+	err := initWorkRoot()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	result, err := runAPlusB("./data/codes/APlusB/nofile.c", "")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	err = analysisResult("open files default", result, constants.JudgeFlagAC)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	result, err = runJudgeWith(aPlusBProblem, "./data/codes/APlusB/nofile.c", "", func(session *executor.JudgeSession) {
+		session.JudgeConfig.OpenFilesLimit = 10
+	})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	err = analysisResult("open files 10", result, constants.JudgeFlagWA)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	t.Log("OK")
+}
+
+// Test: Peak memory accounting strategies
+func TestAPlusBProblemMemoryStrategy(t *testing.T) {
+	err := initWorkRoot()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	for _, strategy := range []string{"minflt", "maxrss", "vmhwm"} {
+		result, err := runJudgeWith(aPlusBProblem, "./data/codes/APlusB/ac.c", "", func(session *executor.JudgeSession) {
+			session.MemoryStrategy = strategy
+		})
+		if err != nil {
+			t.Fatal(err)
+			return
+		}
+		err = analysisResult("memory strategy "+strategy, result, constants.JudgeFlagAC)
+		if err != nil {
+			t.Fatal(err)
+			return
+		}
+		for _, tc := range result.TestCases {
+			// 进程在第一次采样之前就退出时，vmhwm会退回到maxrss
+			if tc.MemoryStrategy != strategy && !(strategy == "vmhwm" && tc.MemoryStrategy == "maxrss") {
+				t.Fatalf("expect memory strategy %s, got %s", strategy, tc.MemoryStrategy)
+				return
+			}
+			if tc.MemoryUsed <= 0 {
+				t.Fatalf("memory strategy %s: no memory used", strategy)
+				return
+			}
+		}
+	}
+	result, err := runJudgeWith(aPlusBProblem, "./data/codes/APlusB/ac.c", "", func(session *executor.JudgeSession) {
+		session.MemoryStrategy = "unknown"
+	})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	err = analysisResult("memory strategy unknown", result, constants.JudgeFlagSE)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	t.Log("OK")
+}
+
+// Test: Resource usage timeline
+func TestAPlusBProblemTimeline(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Log("Timeline: Skip")
+		return
+	}
+	err := initWorkRoot()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	result, err := runJudgeWith(aPlusBProblem, "./data/codes/APlusB/grow.c", "", func(session *executor.JudgeSession) {
+		session.TimelineInterval = 10
+	})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	err = analysisResult("timeline", result, constants.JudgeFlagAC)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	for _, tc := range result.TestCases {
+		if len(tc.Timeline) == 0 || len(tc.Timeline) > constants.TimelineMaxSamples {
+			t.Fatalf("unexpected timeline length: %d", len(tc.Timeline))
+			return
+		}
+		last := tc.Timeline[len(tc.Timeline)-1]
+		if last.RSS <= tc.Timeline[0].RSS {
+			t.Fatalf("expect memory to grow, got %+v", tc.Timeline)
+			return
+		}
+	}
+	t.Log("OK")
+}
+
+// Test: Pin to a CPU core
+func TestAPlusBProblemCPUCore(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Log("CPU core: Skip")
+		return
+	}
+	err := initWorkRoot()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	result, err := runJudgeWith(aPlusBProblem, "./data/codes/APlusB/ac.c", "", func(session *executor.JudgeSession) {
+		session.CPUCore = 0
+	})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	err = analysisResult("cpu core 0", result, constants.JudgeFlagAC)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if result.CPUCore != 0 {
+		t.Fatalf("expect cpu core 0 in result, got %d", result.CPUCore)
+		return
+	}
+	// 绑定了核心时不并发运行
+	var session *executor.JudgeSession
+	result, err = runJudgeWith(aPlusBProblem, "./data/codes/APlusB/ac.c", "", func(s *executor.JudgeSession) {
+		session = s
+		s.CPUCore = 0
+		s.Parallelism = 4
+	})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	err = analysisResult("cpu core 0 in parallel", result, constants.JudgeFlagAC)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if session.Parallelism != 1 {
+		t.Fatalf("expect parallelism 1 on a pinned core, got %d", session.Parallelism)
+		return
+	}
+	result, err = runAPlusB("./data/codes/APlusB/ac.c", "")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if result.CPUCore != -1 {
+		t.Fatalf("expect not pinned, got %d", result.CPUCore)
+		return
+	}
+	t.Log("OK")
+}
+
+// Test: Run test cases in parallel with the same result as running one by one
+func TestAPlusBProblemParallel(t *testing.T) {
+	err := initWorkRoot()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	// 第3组（下标2）答案不对
+	cases := make([][2]string, 8)
+	for i := range cases {
+		cases[i] = [2]string{"1.in", "1.out"}
+	}
+	cases[2] = [2]string{"0.in", "1.out"}
+	for _, strict := range []bool{true, false} {
+		inParallel := func(parallelism int) func(session *executor.JudgeSession) {
+			return func(session *executor.JudgeSession) {
+				session.Parallelism = parallelism
+				session.JudgeConfig.StrictMode = strict
+				session.JudgeConfig.TestCases = aPlusBCases(cases)
+			}
+		}
+		expect, err := runJudgeWith(aPlusBProblem, "./data/codes/APlusB/ac.c", "", inParallel(0))
+		if err != nil {
+			t.Fatal(err)
+			return
+		}
+		for round := 0; round < 3; round++ {
+			result, err := runJudgeWith(aPlusBProblem, "./data/codes/APlusB/ac.c", "", inParallel(4))
+			if err != nil {
+				t.Fatal(err)
+				return
+			}
+			if result.JudgeResult != expect.JudgeResult || len(result.TestCases) != len(expect.TestCases) {
+				t.Fatalf("strict %v: expect %d with %d cases, got %d with %d cases",
+					strict, expect.JudgeResult, len(expect.TestCases), result.JudgeResult, len(result.TestCases))
+				return
+			}
+			for i, tc := range result.TestCases {
+				if tc.Handle != expect.TestCases[i].Handle || tc.JudgeResult != expect.TestCases[i].JudgeResult {
+					t.Fatalf("strict %v: case %d differs: %s %d / %s %d", strict, i,
+						tc.Handle, tc.JudgeResult, expect.TestCases[i].Handle, expect.TestCases[i].JudgeResult)
+					return
+				}
+			}
+		}
+		t.Logf("strict %v: %d cases", strict, len(expect.TestCases))
+	}
+	t.Log("OK")
+}
+
+// Test: Cancel the running cases after the first failure
+func TestAPlusBProblemParallelCancel(t *testing.T) {
+	err := initWorkRoot()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	// 第1组答案不对，其余的每组要运行2秒
+	cases := make([][2]string, 8)
+	for i := range cases {
+		cases[i] = [2]string{"1.in", "1.out"}
+	}
+	cases[0] = [2]string{"0.in", "1.out"}
+	start := time.Now()
+	result, err := runJudgeWith(aPlusBProblem, "./data/codes/APlusB/slow.c", "", func(session *executor.JudgeSession) {
+		session.Parallelism = 4
+		session.JudgeConfig.StrictMode = true
+		session.JudgeConfig.TestCases = aPlusBCases(cases)
+	})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	err = analysisResult("parallel cancel", result, constants.JudgeFlagWA)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if len(result.TestCases) != 1 {
+		t.Fatalf("expect 1 case, got %d", len(result.TestCases))
+		return
+	}
+	if elapsed := time.Since(start); elapsed > 1500*time.Millisecond {
+		t.Fatalf("running cases were not cancelled: %s", elapsed)
+		return
+	}
+	t.Log("OK")
+}
+
+// Test: Subtask scoring with dependencies
+func TestAPlusBProblemSubtasks(t *testing.T) {
+	err := initWorkRoot()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	// 第2组（handle为1）答案不对
+	cases := [][2]string{{"1.in", "1.out"}, {"0.in", "1.out"}, {"1.in", "1.out"}, {"0.in", "0.out"}}
+	subtasks := []commonStructs.Subtask{
+		{Name: "basic", Score: 10, Cases: []string{"0", "3"}},
+		{Name: "all", Score: 20, Cases: []string{"1", "2"}},
+		{Name: "sum", Score: 30, Policy: constants.SubtaskPolicySum, Cases: []string{"1", "2", "3", "0"}},
+		{Name: "min", Score: 20, Policy: constants.SubtaskPolicyMin, Cases: []string{"3", "1"}},
+		{Name: "advanced", Score: 20, Cases: []string{"0"}, DependsOn: []string{"all"}},
+	}
+	result, err := runJudgeWith(aPlusBProblem, "./data/codes/APlusB/ac.c", "", func(session *executor.JudgeSession) {
+		session.JudgeConfig.TestCases = aPlusBCases(cases)
+		session.JudgeConfig.Subtasks = subtasks
+	})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	err = analysisResult("subtasks", result, constants.JudgeFlagWA)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if result.Score != 10+22.5 {
+		t.Fatalf("expect score 32.5, got %g", result.Score)
+		return
+	}
+	if len(result.TestCases) != 4 {
+		t.Fatalf("expect 4 cases, got %d", len(result.TestCases))
+		return
+	}
+	expect := []struct {
+		score       float64
+		judgeResult int
+		skipped     int
+		dependency  string
+	}{
+		{10, constants.JudgeFlagAC, 0, ""},
+		{0, constants.JudgeFlagWA, 1, ""},
+		{22.5, constants.JudgeFlagWA, 0, ""},
+		{0, constants.JudgeFlagWA, 0, ""},
+		{0, constants.JudgeFlagWA, 1, "all"},
+	}
+	for i, st := range result.Subtasks {
+		e := expect[i]
+		if st.Score != e.score || st.JudgeResult != e.judgeResult || len(st.Skipped) != e.skipped || st.Dependency != e.dependency {
+			t.Fatalf("subtask %s: expect %+v, got %+v", st.Name, e, st)
+			return
+		}
+	}
+	t.Log("OK")
+
+	// 循环依赖和不存在的测试数据
+	for _, bad := range [][]commonStructs.Subtask{
+		{{Name: "a", Score: 50, Cases: []string{"0"}, DependsOn: []string{"b"}}, {Name: "b", Score: 50, Cases: []string{"0"}, DependsOn: []string{"a"}}},
+		{{Name: "a", Score: 100, Cases: []string{"9"}}},
+	} {
+		result, err = runJudgeWith(aPlusBProblem, "./data/codes/APlusB/ac.c", "", func(session *executor.JudgeSession) {
+			session.JudgeConfig.TestCases = aPlusBCases(cases)
+			session.JudgeConfig.Subtasks = bad
+		})
+		if err != nil {
+			t.Fatal(err)
+			return
+		}
+		if result.JudgeResult != constants.JudgeFlagSE {
+			t.Fatalf("expect SE, got %d", result.JudgeResult)
+			return
+		}
+		t.Log(result.SeInfo)
+	}
+}
+
+// Test: Weighted case scores and partial scores from a classical checker
+func TestAPlusBProblemScore(t *testing.T) {
+	err := initWorkRoot()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer os.RemoveAll("./data/problems/APlusB/bin")
+	// wa.c输出a*b：第1组全对，第2组6个数里对了2个
+	cases := []commonStructs.TestCase{
+		{Handle: "1", Input: "0.in", Output: "0.out"},
+		{Handle: "2", Input: "1.in", Output: "1.out"},
+	}
+	result, err := runJudgeWith(aPlusBProblem, "./data/codes/APlusB/wa.c", "", func(session *executor.JudgeSession) {
+		session.JudgeConfig.TestCases = cases
+	})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if result.Score != 1 || result.MaxScore != 2 {
+		t.Fatalf("expect 1 / 2, got %g / %g", result.Score, result.MaxScore)
+		return
+	}
+	cases[0].Score = 1
+	cases[1].Score = 3
+	result, err = runJudgeWith(aPlusBProblem, "./data/codes/APlusB/wa.c", "", func(session *executor.JudgeSession) {
+		session.JudgeConfig.TestCases = cases
+		useScoreChecker(session, "../../codes/APlusB/score_checker.c")
+	})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	err = analysisResult("score checker", result, constants.JudgeFlagWA)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if math.Abs(result.Score-2) > 1e-4 || result.MaxScore != 4 {
+		t.Fatalf("expect 2 / 4, got %g / %g", result.Score, result.MaxScore)
+		return
+	}
+	if len(result.TestCases) != 2 || result.TestCases[0].Score != 1 || math.Abs(result.TestCases[1].Score-1.0/3) > 1e-4 {
+		t.Fatalf("unexpected case scores: %+v", result.TestCases)
+		return
+	}
+	t.Log("OK")
+}
+
+// Test: Partial points from a testlib checker calling quitp
+func TestAPlusBProblemTestlibPoints(t *testing.T) {
+	err := initWorkRoot()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer os.RemoveAll("./data/problems/APlusB/bin")
+	// wa.c输出a*b：第1组全对（1分），第2组6个数里对了2个（满分6分）
+	result, err := runJudgeWith(aPlusBProblem, "./data/codes/APlusB/wa.c", "", func(session *executor.JudgeSession) {
+		session.JudgeConfig.TestCases = []commonStructs.TestCase{
+			{Handle: "1", Input: "0.in", Output: "0.out", Score: 1},
+			{Handle: "2", Input: "1.in", Output: "1.out", Score: 6},
+		}
+		session.JudgeConfig.SpecialJudge.Mode = constants.SpecialJudgeModeChecker
+		session.JudgeConfig.SpecialJudge.Name = "points_checker"
+		session.JudgeConfig.SpecialJudge.CheckerLang = "gcc"
+		session.JudgeConfig.SpecialJudge.Checker = "../../codes/APlusB/points_checker.c"
+		session.JudgeConfig.SpecialJudge.UseTestlib = true
+	})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	err = analysisResult("testlib points", result, constants.JudgeFlagWA)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if math.Abs(result.Score-3) > 1e-4 || result.MaxScore != 7 {
+		t.Fatalf("expect 3 / 7, got %g / %g", result.Score, result.MaxScore)
+		return
+	}
+	if len(result.TestCases) != 2 {
+		t.Fatalf("expect 2 cases, got %d", len(result.TestCases))
+		return
+	}
+	// 拿到满分的测试数据视为通过
+	first, second := result.TestCases[0], result.TestCases[1]
+	if first.JudgeResult != constants.JudgeFlagAC || first.Points != 1 || first.Score != 1 {
+		t.Fatalf("unexpected 1st case: %+v", first)
+		return
+	}
+	if second.JudgeResult != constants.JudgeFlagWA || second.Points != 2 || math.Abs(second.Score-1.0/3) > 1e-4 ||
+		second.SPJMsg != "2 of 6 numbers matched" {
+		t.Fatalf("unexpected 2nd case: %+v", second)
+		return
+	}
+	t.Log("OK")
+}
+
+// Test: Judge policy
+func TestAPlusBProblemJudgePolicy(t *testing.T) {
+	err := initWorkRoot()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	// 第2、4组答案不对
+	waCases := [][2]string{{"1.in", "1.out"}, {"0.in", "1.out"}, {"1.in", "1.out"}, {"0.in", "1.out"}, {"1.in", "1.out"}}
+	// wa2.c只输出第一组的和（没有换行）：第1组PE，第2组WA
+	peCases := [][2]string{{"0.in", "0.out"}, {"1.in", "1.out"}}
+	tests := []struct {
+		name    string
+		code    string
+		policy  commonStructs.JudgePolicy
+		cases   [][2]string
+		verdict int
+		judged  int
+	}{
+		{"stop", "ac.c", commonStructs.JudgePolicy{OnFailure: constants.JudgePolicyStop}, waCases, constants.JudgeFlagWA, 2},
+		{"run all", "ac.c", commonStructs.JudgePolicy{OnFailure: constants.JudgePolicyRunAll}, waCases, constants.JudgeFlagWA, 5},
+		{"max failures", "ac.c", commonStructs.JudgePolicy{OnFailure: constants.JudgePolicyRunAll, MaxFailures: 2}, waCases, constants.JudgeFlagWA, 4},
+		{"pe fail", "wa2.c", commonStructs.JudgePolicy{PresentationError: constants.JudgePolicyPEFail}, peCases, constants.JudgeFlagPE, 1},
+		{"pe worst", "wa2.c", commonStructs.JudgePolicy{PresentationError: constants.JudgePolicyPEContinue}, peCases, constants.JudgeFlagWA, 2},
+		{"pe first", "wa2.c", commonStructs.JudgePolicy{PresentationError: constants.JudgePolicyPEContinue, Verdict: constants.JudgePolicyVerdictFirst}, peCases, constants.JudgeFlagPE, 2},
+		{"pe accept", "wa2.c", commonStructs.JudgePolicy{PresentationError: constants.JudgePolicyPEAccept, Verdict: constants.JudgePolicyVerdictFirst}, peCases, constants.JudgeFlagWA, 2},
+		{"unknown", "ac.c", commonStructs.JudgePolicy{OnFailure: "never"}, waCases, constants.JudgeFlagSE, 0},
+	}
+	for _, tt := range tests {
+		result, err := runJudgeWith(aPlusBProblem, "./data/codes/APlusB/"+tt.code, "", func(session *executor.JudgeSession) {
+			session.JudgeConfig.JudgePolicy = tt.policy
+			session.JudgeConfig.TestCases = aPlusBCases(tt.cases)
+		})
+		if err != nil {
+			t.Fatal(err)
+			return
+		}
+		if result.JudgeResult != tt.verdict || len(result.TestCases) != tt.judged {
+			t.Fatalf("%s: expect %d with %d cases, got %d with %d cases",
+				tt.name, tt.verdict, tt.judged, result.JudgeResult, len(result.TestCases))
+			return
+		}
+	}
+	t.Log("OK")
+}
+
+// Test: Cancel the judgement
+func TestAPlusBProblemCancel(t *testing.T) {
+	err := initWorkRoot()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	// 编译之前就取消了
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err := runJudgeContext(ctx, aPlusBProblem, "./data/codes/APlusB/ac.c", "", nil)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if result.JudgeResult != constants.JudgeFlagCancelled {
+		t.Fatalf("expect cancelled before compiling, got %d", result.JudgeResult)
+		return
+	}
+	// 运行第2组的时候取消（要运行2秒）
+	ctx, cancel = context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	result, err = runJudgeContext(ctx, aPlusBProblem, "./data/codes/APlusB/slow.c", "", nil)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	err = analysisResult("cancel", result, constants.JudgeFlagCancelled)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if elapsed := time.Since(start); elapsed > 1500*time.Millisecond {
+		t.Fatalf("judgement was not cancelled: %s", elapsed)
+		return
+	}
+	if len(result.TestCases) != 2 || result.TestCases[1].JudgeResult != constants.JudgeFlagCancelled {
+		t.Fatalf("expect the 2nd case cancelled, got %+v", result.TestCases)
+		return
+	}
+	t.Log("OK")
+}
+
+// Test: Builtin checkers
+func TestAPlusBProblemBuiltinChecker(t *testing.T) {
+	err := initWorkRoot()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	tests := []struct {
+		name    string
+		code    string
+		checker string
+		verdict int
+		message string
+	}{
+		{"wcmp", "ac.c", "wcmp", constants.JudgeFlagAC, "6 tokens"},
+		{"ncmp", "ac.c", "ncmp", constants.JudgeFlagAC, "6 numbers"},
+		{"rcmp6", "ac.c", "rcmp6", constants.JudgeFlagAC, "6 numbers"},
+		{"wa", "wa.c", "wcmp", constants.JudgeFlagWA, "1st words differ - expected: '2', found: '1'"},
+		{"pe", "pe.c", "ncmp", constants.JudgeFlagPE, "Expected integer, but \"246-1-20\" found"},
+		{"unknown", "ac.c", "nothing", constants.JudgeFlagSE, ""},
+	}
+	for _, tt := range tests {
+		result, err := runJudgeWith(aPlusBProblem, "./data/codes/APlusB/"+tt.code, "", func(session *executor.JudgeSession) {
+			useVisibleCases(session)
+			session.JudgeConfig.SpecialJudge.Mode = constants.SpecialJudgeModeChecker
+			session.JudgeConfig.SpecialJudge.Builtin = tt.checker
+		})
+		if err != nil {
+			t.Fatal(err)
+			return
+		}
+		if result.JudgeResult != tt.verdict {
+			t.Fatalf("%s: expect %d, got %d", tt.name, tt.verdict, result.JudgeResult)
+			return
+		}
+		if tt.message == "" {
+			continue
+		}
+		msg := result.TestCases[len(result.TestCases)-1].SPJMsg
+		if !strings.Contains(msg, tt.message) {
+			t.Fatalf("%s: expect message contains %q, got %q", tt.name, tt.message, msg)
+			return
+		}
+	}
+	t.Log("OK")
+}
+
+// Test: Floating-point tolerant compare mode
+func TestAPlusBProblemFloatCompare(t *testing.T) {
+	err := initWorkRoot()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	float := constants.CompareModeFloat
+	tests := []struct {
+		name    string
+		code    string
+		compare commonStructs.CompareOptions
+		verdict int
+		log     string
+	}{
+		{"ac", "ac.c", commonStructs.CompareOptions{Mode: float}, constants.JudgeFlagAC, "Accepted."},
+		// 逐字符比较的时候，输出超过参考答案的2倍会被判为OLE
+		{"text", "float.c", commonStructs.CompareOptions{}, constants.JudgeFlagOLE, ""},
+		{"default epsilon", "float.c", commonStructs.CompareOptions{Mode: float}, constants.JudgeFlagWA,
+			`WA: at token=0, expected="4", found="4.0001", absError=0.0001`},
+		{"absolute epsilon", "float.c", commonStructs.CompareOptions{Mode: float, AbsoluteEpsilon: 1e-3}, constants.JudgeFlagAC, "Accepted."},
+		// 参考答案为0时只能按绝对误差比较
+		{"relative epsilon", "float.c", commonStructs.CompareOptions{Mode: float, RelativeEpsilon: 1e-4}, constants.JudgeFlagWA,
+			`WA: at token=5, expected="0", found="0.0001", absError=0.0001, relError=0.0001`},
+		{"small relative epsilon", "float.c", commonStructs.CompareOptions{Mode: float, RelativeEpsilon: 1e-5}, constants.JudgeFlagWA,
+			`WA: at token=0, expected="4", found="4.0001", absError=0.0001, relError=2.5e-05`},
+		{"wa", "wa.c", commonStructs.CompareOptions{Mode: float}, constants.JudgeFlagWA,
+			`WA: at token=0, expected="2", found="1", absError=1, relError=0.5`},
+		{"pe", "pe.c", commonStructs.CompareOptions{Mode: float}, constants.JudgeFlagWA, `found="246-1-20", not a number`},
+		{"unknown", "ac.c", commonStructs.CompareOptions{Mode: "exact"}, constants.JudgeFlagSE, ""},
+	}
+	for _, tt := range tests {
+		result, err := runJudgeWith(aPlusBProblem, "./data/codes/APlusB/"+tt.code, "", func(session *executor.JudgeSession) {
+			useVisibleCases(session)
+			session.JudgeConfig.Compare = tt.compare
+		})
+		if err != nil {
+			t.Fatal(err)
+			return
+		}
+		if result.JudgeResult != tt.verdict {
+			t.Fatalf("%s: expect %d, got %d", tt.name, tt.verdict, result.JudgeResult)
+			return
+		}
+		if tt.log == "" {
+			continue
+		}
+		log := result.TestCases[len(result.TestCases)-1].TextDiffLog
+		if !strings.Contains(log, tt.log) {
+			t.Fatalf("%s: expect diff log contains %q, got %q", tt.name, tt.log, log)
+			return
+		}
+	}
+	t.Log("OK")
+}
+
+// Test: Hidden test cases never leak the participant output or the answer
+func TestAPlusBProblemHiddenCase(t *testing.T) {
+	err := initWorkRoot()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	float := func(session *executor.JudgeSession) {
+		session.JudgeConfig.Compare.Mode = constants.CompareModeFloat
+	}
+	builtin := func(name string) func(session *executor.JudgeSession) {
+		return func(session *executor.JudgeSession) {
+			session.JudgeConfig.SpecialJudge.Mode = constants.SpecialJudgeModeChecker
+			session.JudgeConfig.SpecialJudge.Builtin = name
+		}
+	}
+	// 只保留单词的位置和误差
+	tests := []struct {
+		name    string
+		code    string
+		setup   func(session *executor.JudgeSession)
+		verdict int
+		log     string
+		message string
+	}{
+		{"float wa", "wa.c", float, constants.JudgeFlagWA, "WA: at token=0, absError=1, relError=0.5", ""},
+		{"float pe", "pe.c", float, constants.JudgeFlagWA, "WA: at token=0, not a number", ""},
+		{"wcmp", "wa.c", builtin("wcmp"), constants.JudgeFlagWA, "", "1st words differ"},
+		{"ncmp", "pe.c", builtin("ncmp"), constants.JudgeFlagPE, "", "Expected integer"},
+		{"rcmp", "float.c", builtin("rcmp6"), constants.JudgeFlagWA, "", "1st numbers differ - error = '0.000025'"},
+		{"ac", "ac.c", builtin("ncmp"), constants.JudgeFlagAC, "", "6 numbers"},
+	}
+	for _, tt := range tests {
+		result, err := runJudgeWith(aPlusBProblem, "./data/codes/APlusB/"+tt.code, "", tt.setup)
+		if err != nil {
+			t.Fatal(err)
+			return
+		}
+		if result.JudgeResult != tt.verdict {
+			t.Fatalf("%s: expect %d, got %d", tt.name, tt.verdict, result.JudgeResult)
+			return
+		}
+		rst := result.TestCases[len(result.TestCases)-1]
+		if rst.SPJMsg != tt.message || !strings.HasSuffix(rst.TextDiffLog, tt.log) {
+			t.Fatalf("%s: expect message %q and diff log %q, got %q and %q", tt.name, tt.message, tt.log, rst.SPJMsg, rst.TextDiffLog)
+			return
+		}
+		if strings.Contains(rst.TextDiffLog, "expected") || strings.Contains(rst.TextDiffLog, "found") {
+			t.Fatalf("%s: hidden test case leaks its content: %q", tt.name, rst.TextDiffLog)
+			return
+		}
+	}
+	t.Log("OK")
+}
+
+// Test: Output-only problem
+func TestAPlusBProblemOutputOnly(t *testing.T) {
+	err := initWorkRoot()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer os.RemoveAll("./data/problems/APlusB/bin")
+	root, err := ioutil.TempDir("", "deer-outputs-")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer os.RemoveAll(root)
+	// 提交的是输出文件，两组测试数据分别占30分和70分
+	outputOnly := func(setup func(session *executor.JudgeSession)) func(session *executor.JudgeSession) {
+		return func(session *executor.JudgeSession) {
+			session.JudgeConfig.OutputOnly = true
+			session.JudgeConfig.TestCases[0].Score = 30
+			session.JudgeConfig.TestCases[1].Score = 70
+			if setup != nil {
+				setup(session)
+			}
+		}
+	}
+	scoreChecker := func(session *executor.JudgeSession) {
+		useScoreChecker(session, "../../codes/APlusB/score_checker.c")
+	}
+	builtinChecker := func(session *executor.JudgeSession) {
+		session.JudgeConfig.SpecialJudge.Mode = constants.SpecialJudgeModeChecker
+		session.JudgeConfig.SpecialJudge.Builtin = "ncmp"
+	}
+	answer := "2\n4\n6\n-1\n-2\n0\n"
+	tests := []struct {
+		name    string
+		files   map[string]string
+		zipped  bool
+		setup   func(session *executor.JudgeSession)
+		verdict int
+		cases   []int
+		score   float64
+	}{
+		{"accepted", map[string]string{"1.out": "4\n", "2.out": answer}, true, nil,
+			constants.JudgeFlagAC, []int{constants.JudgeFlagAC, constants.JudgeFlagAC}, 100},
+		{"directory", map[string]string{"out/1.txt": "4\n", "out/2": answer}, false, nil,
+			constants.JudgeFlagAC, []int{constants.JudgeFlagAC, constants.JudgeFlagAC}, 100},
+		// 严格模式下也会评测所有测试数据
+		{"wrong answer", map[string]string{"1.out": "5\n", "2.out": answer, "readme.txt": "hello"}, true, nil,
+			constants.JudgeFlagWA, []int{constants.JudgeFlagWA, constants.JudgeFlagAC}, 70},
+		{"missing", map[string]string{"1.out": "4\n"}, true, nil,
+			constants.JudgeFlagWA, []int{constants.JudgeFlagAC, constants.JudgeFlagWA}, 30},
+		{"builtin checker", map[string]string{"1.out": "4", "2.out": "2 4 6 -1 -2 0"}, true, builtinChecker,
+			constants.JudgeFlagAC, []int{constants.JudgeFlagAC, constants.JudgeFlagAC}, 100},
+		// 6个数对了3个，得一半的分
+		{"partial score", map[string]string{"1.out": "4\n", "2.out": "2 4 6 1 2 3\n"}, true, scoreChecker,
+			constants.JudgeFlagWA, []int{constants.JudgeFlagAC, constants.JudgeFlagWA}, 65},
+		{"duplicate", map[string]string{"1.out": "4\n", "1.txt": "4\n"}, true, nil,
+			constants.JudgeFlagCE, nil, 0},
+	}
+	for _, tt := range tests {
+		source, err := writeOutputFiles(root, strings.Replace(tt.name, " ", "_", -1), tt.files, tt.zipped)
+		if err != nil {
+			t.Fatal(err)
+			return
+		}
+		result, err := runJudgeWith(aPlusBProblem, source, "", outputOnly(tt.setup))
+		if err != nil {
+			t.Fatal(err)
+			return
+		}
+		verdicts := make([]int, 0)
+		for _, tc := range result.TestCases {
+			verdicts = append(verdicts, tc.JudgeResult)
+		}
+		if result.JudgeResult != tt.verdict || len(verdicts) != len(tt.cases) || math.Abs(result.Score-tt.score) > 1e-6 {
+			t.Fatalf("%s: expect %d %v with score %v, got %d %v with score %v",
+				tt.name, tt.verdict, tt.cases, tt.score, result.JudgeResult, verdicts, result.Score)
+			return
+		}
+		for i := range verdicts {
+			if verdicts[i] != tt.cases[i] {
+				t.Fatalf("%s: expect %v, got %v", tt.name, tt.cases, verdicts)
+				return
+			}
+		}
+	}
+
+	// 不是压缩包
+	result, err := runJudgeWith(aPlusBProblem, "./data/problems/APlusB/0.out", "", outputOnly(nil))
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if result.JudgeResult != constants.JudgeFlagCE {
+		t.Fatalf("expect CE for a broken archive, got %d", result.JudgeResult)
+		return
+	}
+	t.Log("OK")
+}
@@ -0,0 +1,454 @@
+package test
+
+import (
+	"context"
+	"github.com/LanceLRQ/deer-executor/v2/common/sandbox"
+	"github.com/LanceLRQ/deer-executor/v2/common/sandbox/cmd"
+	"github.com/LanceLRQ/deer-executor/v2/common/sandbox/forkexec"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// 在沙箱里运行一个命令，stdout写入临时文件
+func runSandbox(ctx context.Context, args []string, limits forkexec.ExecRLimit) (*sandbox.Usage, string, error) {
+	stdout, err := ioutil.TempFile("", "deer-sandbox-*.out")
+	if err != nil {
+		return nil, "", err
+	}
+	defer os.Remove(stdout.Name())
+	defer stdout.Close()
+	usage, err := sandbox.Run(ctx, sandbox.Spec{
+		Args:   args,
+		Env:    []string{"PATH=/usr/bin:/bin"},
+		Files:  []interface{}{os.Stdin, stdout, os.Stderr},
+		Limits: limits,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	out, err := ioutil.ReadFile(stdout.Name())
+	return usage, string(out), err
+}
+
+// Test: Run a command
+func TestSandboxRun(t *testing.T) {
+	usage, out, err := runSandbox(context.Background(), []string{"echo", "hello"}, forkexec.ExecRLimit{
+		TimeLimit:   1000,
+		MemoryLimit: 65535,
+	})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if strings.TrimSpace(out) != "hello" {
+		t.Fatalf("unexpected output: %s", out)
+	}
+	if usage.ExitCode != 0 || usage.Signal != 0 || usage.LimitHit != sandbox.LimitNone {
+		t.Fatalf("unexpected usage: %+v", usage)
+	}
+	t.Log("OK")
+}
+
+// Test: Exit code
+func TestSandboxExitCode(t *testing.T) {
+	usage, _, err := runSandbox(context.Background(), []string{"sh", "-c", "exit 3"}, forkexec.ExecRLimit{})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if usage.ExitCode != 3 {
+		t.Fatalf("expect exit code 3, got %d", usage.ExitCode)
+	}
+	t.Log("OK")
+}
+
+// Test: Real time limit
+func TestSandboxRealTimeLimit(t *testing.T) {
+	usage, _, err := runSandbox(context.Background(), []string{"sleep", "10"}, forkexec.ExecRLimit{
+		RealTimeLimit: 500,
+	})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if usage.LimitHit != sandbox.LimitRealTime {
+		t.Fatalf("expect real time limit, got %+v", usage)
+	}
+	t.Log("OK")
+}
+
+// Test: Cancel by context
+func TestSandboxCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	startTime := time.Now()
+	_, _, err := runSandbox(ctx, []string{"sleep", "10"}, forkexec.ExecRLimit{})
+	if err != context.DeadlineExceeded {
+		t.Fatalf("expect context deadline exceeded, got %v", err)
+	}
+	if time.Since(startTime) > 2*time.Second {
+		t.Fatalf("process is not killed in time")
+	}
+	t.Log("OK")
+}
+
+// Test: Traced processes may exec only once
+func TestSandboxTraceExec(t *testing.T) {
+	if runtime.GOOS != "linux" || runtime.GOARCH != "amd64" {
+		t.Log("Trace exec: Skip")
+		return
+	}
+	cases := []struct {
+		args       []string
+		restricted int
+	}{
+		{[]string{"/bin/true"}, -1},
+		// 不加载过滤规则，只由跟踪进程拦截第二次exec
+		{[]string{"/bin/sh", "-c", "exec /bin/true"}, syscall.SYS_EXECVE},
+	}
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	for _, c := range cases {
+		proc, err := cmd.StartProcess(c.args[0], c.args, &cmd.ProcAttr{
+			Env:   []string{"PATH=/usr/bin:/bin"},
+			Files: []interface{}{os.Stdin, os.Stdout, os.Stderr},
+			Sys:   &forkexec.SysProcAttr{Ptrace: true, Setpgid: true},
+		})
+		if err != nil {
+			t.Fatal(err)
+			return
+		}
+		pstate, restricted, err := proc.WaitSeccomp()
+		if err != nil {
+			t.Fatal(err)
+			return
+		}
+		if restricted != c.restricted {
+			t.Fatalf("%v: expect restricted syscall %d, got %d", c.args, c.restricted, restricted)
+		}
+		if killed := pstate.Sys().(syscall.WaitStatus).Signaled(); killed != (c.restricted >= 0) {
+			t.Fatalf("%v: unexpected status: %s", c.args, pstate.String())
+		}
+	}
+	t.Log("OK")
+}
+
+// Test: Only the running user is mapped into the isolated user namespace
+func TestSandboxIsolationIDMap(t *testing.T) {
+	if runtime.GOOS != "linux" || os.Geteuid() != 0 {
+		t.Log("Isolation: Skip")
+		return
+	}
+	stdout, err := ioutil.TempFile("", "deer-sandbox-*.out")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer os.Remove(stdout.Name())
+	defer stdout.Close()
+	usage, err := sandbox.Run(context.Background(), sandbox.Spec{
+		Args:  []string{"cat", "/proc/self/uid_map", "/proc/self/gid_map"},
+		Env:   []string{"PATH=/usr/bin:/bin"},
+		Files: []interface{}{os.Stdin, stdout, os.Stderr},
+		Isolation: &sandbox.Isolation{
+			Mounts: []sandbox.Mount{{Source: "/usr"}, {Source: "/lib"}, {Source: "/lib64"}, {Source: "/bin"}},
+			UID:    30000,
+			GID:    30001,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	out, err := ioutil.ReadFile(stdout.Name())
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if fields := strings.Fields(string(out)); usage.ExitCode != 0 || strings.Join(fields, " ") != "30000 30000 1 30001 30001 1" {
+		t.Fatalf("unexpected id maps (exit code %d): %q", usage.ExitCode, string(out))
+	}
+	t.Log("OK")
+}
+
+// Test: Leased users are distinct and their leftover processes are killed on release
+func TestSandboxUserPool(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Log("User pool: Skip (requires root)")
+		return
+	}
+	pool, err := sandbox.NewUserPool(30000, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	user1, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	user2, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if user1.UID == user2.UID || user1.GID == user2.GID {
+		t.Fatalf("expect distinct users, got %+v and %+v", user1, user2)
+	}
+	// 没有空闲的用户时，请求被取消就不再等待
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if user, err := pool.Acquire(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expect context deadline exceeded, got %+v, %v", user, err)
+	}
+	// setsid脱离进程组，进程退出后仍然残留（等待setsid完成，避免被当作进程组里的残留进程杀死）
+	usage, err := sandbox.Run(context.Background(), sandbox.Spec{
+		Args:  []string{"sh", "-c", "setsid sleep 30 > /dev/null 2>&1 & sleep 0.2"},
+		Env:   []string{"PATH=/usr/bin:/bin"},
+		Files: []interface{}{os.Stdin, os.Stdout, os.Stderr},
+		UID:   user1.UID,
+		GID:   user1.GID,
+	})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if usage.ExitCode != 0 {
+		t.Fatalf("unexpected usage: %+v", usage)
+	}
+	if count := pool.Release(user1); count != 1 {
+		t.Fatalf("expect 1 leftover process, got %d", count)
+	}
+	if count := pool.Release(user2); count != 0 {
+		t.Fatalf("expect no leftover process, got %d", count)
+	}
+	t.Log("OK")
+}
+
+// Test: Process/thread limit
+func TestSandboxProcessLimit(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Log("Process limit: Skip")
+		return
+	}
+	usage, _, err := runSandbox(context.Background(), []string{"sh", "-c", "for i in $(seq 1 40); do sleep 5 & done; wait"}, forkexec.ExecRLimit{
+		RealTimeLimit: 3000,
+		ProcessLimit:  10,
+	})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if usage.LimitHit != sandbox.LimitProcess || usage.PeakTasks <= 10 {
+		t.Fatalf("expect process limit, got %+v", usage)
+	}
+	t.Log("OK")
+}
+
+// Test: Process/thread limit enforced by RLIMIT_NPROC when running as a separate user
+func TestSandboxProcessLimitUser(t *testing.T) {
+	if runtime.GOOS != "linux" || os.Geteuid() != 0 {
+		t.Log("Process limit: Skip")
+		return
+	}
+	devnull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer devnull.Close()
+	spec := sandbox.Spec{
+		Args:   []string{"sh", "-c", "for i in $(seq 1 40); do sleep 1 & done; wait"},
+		Env:    []string{"PATH=/usr/bin:/bin"},
+		Files:  []interface{}{os.Stdin, devnull, devnull},
+		Limits: forkexec.ExecRLimit{RealTimeLimit: 5000, ProcessLimit: 10},
+		UID:    30002,
+		GID:    30002,
+	}
+	// 内核拒绝多出来的fork，监控不会杀死进程组，只统计峰值
+	spec.ReportPeakTasks = true
+	usage, err := sandbox.Run(context.Background(), spec)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if usage.LimitHit != sandbox.LimitNone || usage.PeakTasks == 0 || usage.PeakTasks > 10 {
+		t.Fatalf("expect the kernel to refuse forks, got %+v", usage)
+	}
+	// 既不需要限制也不需要峰值时不轮询
+	spec.ReportPeakTasks = false
+	usage, err = sandbox.Run(context.Background(), spec)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if usage.PeakTasks != 0 {
+		t.Fatalf("expect no task polling, got %d peak tasks", usage.PeakTasks)
+	}
+	t.Log("OK")
+}
+
+// Test: Peak memory accounting strategies
+func TestSandboxMemoryStrategy(t *testing.T) {
+	strategies := []sandbox.MemoryStrategy{sandbox.MemoryStrategyMinflt, sandbox.MemoryStrategyMaxRSS}
+	if runtime.GOOS == "linux" {
+		strategies = append(strategies, sandbox.MemoryStrategyVmHWM)
+	}
+	for _, strategy := range strategies {
+		// shell把约20MB的字符串保存在变量里，然后等待一会儿让采样可以读到它
+		usage, err := sandbox.Run(context.Background(), sandbox.Spec{
+			Args:           []string{"sh", "-c", "x=$(head -c 20000000 /dev/zero | tr '\\0' a); sleep 0.2"},
+			Env:            []string{"PATH=/usr/bin:/bin"},
+			Files:          []interface{}{os.Stdin, os.Stdout, os.Stderr},
+			Limits:         forkexec.ExecRLimit{RealTimeLimit: 5000},
+			MemoryStrategy: strategy,
+		})
+		if err != nil {
+			t.Fatal(err)
+			return
+		}
+		if usage.MemoryStrategy != strategy || usage.Memory < 19000 {
+			t.Fatalf("memory strategy %s: got %s, %dKB", strategy, usage.MemoryStrategy, usage.Memory)
+			return
+		}
+		t.Logf("%s: %dKB", strategy, usage.Memory)
+	}
+	_, err := sandbox.Run(context.Background(), sandbox.Spec{
+		Args:           []string{"true"},
+		MemoryStrategy: "unknown",
+	})
+	if err == nil {
+		t.Fatal("expect an error for unknown memory strategy")
+		return
+	}
+	t.Log("OK")
+}
+
+// Test: Resource usage timeline is capped
+func TestSandboxTimeline(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Log("Timeline: Skip")
+		return
+	}
+	usage, err := sandbox.Run(context.Background(), sandbox.Spec{
+		Args:             []string{"sh", "-c", "sleep 0.5"},
+		Env:              []string{"PATH=/usr/bin:/bin"},
+		Files:            []interface{}{os.Stdin, os.Stdout, os.Stderr},
+		Limits:           forkexec.ExecRLimit{RealTimeLimit: 5000},
+		TimelineInterval: 10,
+		TimelineLimit:    8,
+	})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if len(usage.Timeline) < 2 || len(usage.Timeline) > 8 {
+		t.Fatalf("unexpected timeline length: %d", len(usage.Timeline))
+		return
+	}
+	for i := 1; i < len(usage.Timeline); i++ {
+		if usage.Timeline[i].Time <= usage.Timeline[i-1].Time {
+			t.Fatalf("timeline is not in order: %+v", usage.Timeline)
+			return
+		}
+	}
+	// 采样点被多次减半，间隔早已超过10ms，但仍然覆盖整个运行过程
+	if last := usage.Timeline[len(usage.Timeline)-1]; last.Time < 200 {
+		t.Fatalf("timeline does not cover the run: %+v", usage.Timeline)
+		return
+	}
+	t.Log("OK")
+}
+
+// Test: Pin to a CPU core
+func TestSandboxCPUAffinity(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Log("CPU affinity: Skip")
+		return
+	}
+	stdout, err := ioutil.TempFile("", "deer-sandbox-*.out")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer os.Remove(stdout.Name())
+	defer stdout.Close()
+	_, err = sandbox.Run(context.Background(), sandbox.Spec{
+		Args:   []string{"sh", "-c", "grep Cpus_allowed_list /proc/self/status"},
+		Env:    []string{"PATH=/usr/bin:/bin"},
+		Files:  []interface{}{os.Stdin, stdout, os.Stderr},
+		Limits: forkexec.ExecRLimit{RealTimeLimit: 5000},
+		CPUs:   []int{0},
+	})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	out, err := ioutil.ReadFile(stdout.Name())
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if fields := strings.Fields(string(out)); len(fields) != 2 || fields[1] != "0" {
+		t.Fatalf("expect to be pinned to cpu 0, got %s", out)
+		return
+	}
+	t.Log("OK")
+}
+
+// Test: Each session leases a different core
+func TestSandboxCorePool(t *testing.T) {
+	_, err := sandbox.NewCorePool([]int{1, 1})
+	if err == nil {
+		t.Fatal("expect an error for duplicate cores")
+		return
+	}
+	pool, err := sandbox.NewCorePool([]int{2, 3})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	a, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	b, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if a == b {
+		t.Fatalf("leased the same core twice: %d", a)
+		return
+	}
+	// 请求被取消时不再等待
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if core, err := pool.Acquire(ctx); err != context.Canceled {
+		t.Fatalf("expect context canceled, got %d, %v", core, err)
+		return
+	}
+	acquired := make(chan int)
+	go func() {
+		core, _ := pool.Acquire(context.Background())
+		acquired <- core
+	}()
+	select {
+	case core := <-acquired:
+		t.Fatalf("expect to wait for a free core, got %d", core)
+		return
+	case <-time.After(100 * time.Millisecond):
+	}
+	pool.Release(a)
+	if core := <-acquired; core != a {
+		t.Fatalf("expect core %d, got %d", a, core)
+		return
+	}
+	t.Log("OK")
+}
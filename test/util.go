@@ -1,6 +1,8 @@
 package test
 
 import (
+	"archive/zip"
+	"context"
 	"fmt"
 	"github.com/LanceLRQ/deer-executor/v2/common/constants"
 	"github.com/LanceLRQ/deer-executor/v2/common/provider"
@@ -9,6 +11,7 @@ import (
 	"github.com/LanceLRQ/deer-executor/v2/executor"
 	"github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
+	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
@@ -37,10 +40,22 @@ func initWorkRoot() error {
 }
 
 func runJudge(conf, codeFile, codeLang string) (*commonStructs.JudgeResult, error) {
+	return runJudgeWith(conf, codeFile, codeLang, nil)
+}
+
+// setup 用于在评测开始前修改会话的配置
+func runJudgeWith(conf, codeFile, codeLang string, setup func(session *executor.JudgeSession)) (*commonStructs.JudgeResult, error) {
+	return runJudgeContext(context.Background(), conf, codeFile, codeLang, setup)
+}
+
+func runJudgeContext(ctx context.Context, conf, codeFile, codeLang string, setup func(session *executor.JudgeSession)) (*commonStructs.JudgeResult, error) {
 	session, err := executor.NewSession(conf)
 	if err != nil {
 		return nil, err
 	}
+	if setup != nil {
+		setup(session)
+	}
 	session.CodeFile = codeFile
 	session.CodeLangName = codeLang
 	session.SessionRoot = "/tmp"
@@ -52,12 +67,46 @@ func runJudge(conf, codeFile, codeLang string) (*commonStructs.JudgeResult, erro
 	session.SessionDir = sessionDir
 	defer session.Clean()
 	// start judge
-	judgeResult := session.RunJudge()
+	judgeResult := session.RunJudgeContext(ctx)
 	return &judgeResult, err
 }
 
+const aPlusBProblem = "./data/problems/APlusB/problem.json"
+
 func runAPlusB(codeFile, codeLang string) (*commonStructs.JudgeResult, error) {
-	return runJudge("./data/problems/APlusB/problem.json", codeFile, codeLang)
+	return runJudge(aPlusBProblem, codeFile, codeLang)
+}
+
+// 按cases（输入、输出文件）生成测试数据，handle为空时按下标编号
+func aPlusBCases(cases [][2]string) []commonStructs.TestCase {
+	testCases := make([]commonStructs.TestCase, len(cases))
+	for i, c := range cases {
+		testCases[i] = commonStructs.TestCase{Input: c[0], Output: c[1]}
+	}
+	return testCases
+}
+
+// 公开所有的测试数据，比较结果里才会包含选手输出和参考答案的内容
+func useVisibleCases(session *executor.JudgeSession) {
+	for i := range session.JudgeConfig.TestCases {
+		session.JudgeConfig.TestCases[i].Visible = true
+	}
+}
+
+// 在只读的根文件系统里运行目标程序
+func useIsolation(session *executor.JudgeSession) {
+	session.Isolation = commonStructs.IsolationOptions{
+		Enabled: true,
+		Rootfs:  []string{"/usr", "/lib", "/lib64", "/bin", "/etc/ld.so.cache", "/dev/null", "/dev/urandom"},
+	}
+}
+
+// 使用按结果给分的非testlib判题程序（checker是相对于题目目录的路径）
+func useScoreChecker(session *executor.JudgeSession, checker string) {
+	session.JudgeConfig.SpecialJudge.Mode = constants.SpecialJudgeModeChecker
+	session.JudgeConfig.SpecialJudge.Name = "score_checker"
+	session.JudgeConfig.SpecialJudge.CheckerLang = "gcc"
+	session.JudgeConfig.SpecialJudge.Checker = checker
 }
 
 func runWJ2018(codeFile, codeLang string) (*commonStructs.JudgeResult, error) {
@@ -83,3 +132,36 @@ func analysisResult(caseName string, result *commonStructs.JudgeResult, expect i
 	fmt.Printf("[%s] finish with: %s\n", caseName, name)
 	return nil
 }
+
+// 把files（文件名 -> 内容）写到目录里，zip为true时打包成压缩包，返回目录或者压缩包的路径
+func writeOutputFiles(root, name string, files map[string]string, zipped bool) (string, error) {
+	if !zipped {
+		dir := path.Join(root, name)
+		for file, content := range files {
+			if err := os.MkdirAll(path.Dir(path.Join(dir, file)), 0755); err != nil {
+				return "", err
+			}
+			if err := ioutil.WriteFile(path.Join(dir, file), []byte(content), 0644); err != nil {
+				return "", err
+			}
+		}
+		return dir, nil
+	}
+	archive := path.Join(root, name+".zip")
+	fp, err := os.Create(archive)
+	if err != nil {
+		return "", err
+	}
+	defer fp.Close()
+	zw := zip.NewWriter(fp)
+	for file, content := range files {
+		w, err := zw.Create(file)
+		if err != nil {
+			return "", err
+		}
+		if _, err = w.Write([]byte(content)); err != nil {
+			return "", err
+		}
+	}
+	return archive, zw.Close()
+}
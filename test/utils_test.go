@@ -0,0 +1,328 @@
+package test
+
+import (
+	"bufio"
+	"github.com/LanceLRQ/deer-executor/v2/common/checker"
+	"github.com/LanceLRQ/deer-executor/v2/common/constants"
+	commonStructs "github.com/LanceLRQ/deer-executor/v2/common/structs"
+	"github.com/LanceLRQ/deer-executor/v2/common/utils"
+	"github.com/LanceLRQ/deer-executor/v2/executor"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// Test: ASCII sparkline
+func TestSparkline(t *testing.T) {
+	cases := []struct {
+		values []int
+		width  int
+		expect string
+	}{
+		{nil, 10, ""},
+		{[]int{0, 0, 0}, 10, "   "},
+		{[]int{0, 1, 5, 9}, 0, " .+@"},
+		{[]int{0, 9, 0, 0, 9, 9}, 3, "@ @"},
+	}
+	for _, c := range cases {
+		if got := utils.Sparkline(c.values, c.width); got != c.expect {
+			t.Fatalf("Sparkline(%v, %d): expect %q, got %q", c.values, c.width, c.expect, got)
+			return
+		}
+	}
+	t.Log("OK")
+}
+
+// Test: Builtin checkers
+func TestBuiltinCheckers(t *testing.T) {
+	// 过长的单词在判题信息里只保留开头和结尾
+	long := strings.Repeat("a", 30) + strings.Repeat("b", 10) + strings.Repeat("c", 31)
+	cases := []struct {
+		checker string
+		output  string
+		answer  string
+		verdict int
+		message string
+	}{
+		{"wcmp", "hello  world\n", "hello\nworld", constants.JudgeFlagAC, "2 tokens"},
+		{"wcmp", "hello", "hello world", constants.JudgeFlagWA, "Answer contains longer sequence [length = 2], but output contains 1 elements"},
+		{"wcmp", "a b c d e f g h i j k l", "a b c d e f g h i j k m", constants.JudgeFlagWA, "12th words differ - expected: 'm', found: 'l'"},
+		{"wcmp", "x", "x", constants.JudgeFlagAC, "single token: \"x\""},
+		{"wcmp", "a b c", "a b", constants.JudgeFlagWA, "Participant output contains longer sequence [length = 3], but answer contains 2 elements"},
+		{"wcmp", "y", long, constants.JudgeFlagWA, "expected: '" + strings.Repeat("a", 30) + "..." + strings.Repeat("c", 31) + "', found: 'y'"},
+		{"lcmp", "1  2\n3 4\n\n", "1 2\n3 4\n", constants.JudgeFlagAC, "2 lines"},
+		{"lcmp", "1 2 3 4\n", "1 2\n3 4\n", constants.JudgeFlagWA, "1st lines differ"},
+		{"lcmp", "1 2\r\n3 4\r\n\r\n", "1 2\n3 4", constants.JudgeFlagAC, "2 lines"},
+		{"lcmp", "hello   world\n", "hello world", constants.JudgeFlagAC, "single line: 'hello world'"},
+		{"lcmp", "1\n2\n3\n", "1\n2\n", constants.JudgeFlagWA, "Participant output contains longer sequence [length = 3], but answer contains 2 lines"},
+		{"ncmp", "1 -2 3", "1 -2 3", constants.JudgeFlagAC, "3 number(s): \"1 -2 3\""},
+		{"ncmp", "1 2.0 3", "1 2 3", constants.JudgeFlagPE, "Expected integer"},
+		{"ncmp", "1 2 3", "1 2", constants.JudgeFlagWA, "Output contains longer sequence"},
+		{"ncmp", "1 2", "1 x", constants.JudgeFlagSpecialJudgeError, "answer: expected integer"},
+		{"rcmp6", "3.1415926 1000000.5", "3.1415929 1000000", constants.JudgeFlagAC, "2 numbers"},
+		{"rcmp6", "3.14", "3.1415926", constants.JudgeFlagWA, "1st numbers differ - expected: '3.141593', found: '3.140000'"},
+		{"rcmp9", "nan inf", "NaN +Inf", constants.JudgeFlagAC, "2 numbers"},
+		{"rcmp6", "1", "1 2", constants.JudgeFlagWA, "Unexpected EOF in the participants output: expected 2nd number"},
+		{"rcmp9", "-inf", "inf", constants.JudgeFlagWA, "1st numbers differ"},
+		{"rcmp3", "x", "1", constants.JudgeFlagPE, "Expected double, but \"x\" found"},
+		{"rcmp15", "1", "1", constants.JudgeFlagAC, "1 number"},
+		{"yesno", "yes No YES", "YES NO yes", constants.JudgeFlagAC, "3 answers (2 yes, 1 no)"},
+		{"yesno", "yes", "NO", constants.JudgeFlagWA, "expected NO, found YES"},
+		{"yesno", "maybe", "NO", constants.JudgeFlagPE, "YES or NO expected, but MAYBE found"},
+		{"yesno", "YES YES", "YES NO", constants.JudgeFlagWA, "2nd answer: expected NO, found YES"},
+		{"yesno", "YES NO", "YES", constants.JudgeFlagWA, "Output contains more answers than expected 1"},
+		{"yesno", "YES", "OK", constants.JudgeFlagSpecialJudgeError, "answer: YES or NO expected, but OK found"},
+		{"uwcmp", "3 1 2 1", "1 1 2 3", constants.JudgeFlagAC, "4 tokens"},
+		{"uwcmp", "3 1 2 2", "1 1 2 3", constants.JudgeFlagWA, "unexpected token '2' found"},
+		{"uwcmp", "1 2", "1 2 3", constants.JudgeFlagWA, "expected 3 tokens, found 2"},
+	}
+	for _, c := range cases {
+		check, err := checker.Get(c.checker)
+		if err != nil {
+			t.Fatal(err)
+			return
+		}
+		rst := check(strings.NewReader(""), strings.NewReader(c.output), strings.NewReader(c.answer))
+		if rst.Verdict != c.verdict || !strings.Contains(rst.Message, c.message) {
+			t.Fatalf("%s(%q, %q): expect %d %q, got %d %q", c.checker, c.output, c.answer, c.verdict, c.message, rst.Verdict, rst.Message)
+			return
+		}
+	}
+	for _, name := range []string{"rcmp", "rcmp0", "rcmp16", "rcmpx", "nothing"} {
+		if _, err := checker.Get(name); err == nil {
+			t.Fatalf("expect error for unknown checker %s", name)
+			return
+		}
+	}
+	t.Log("OK")
+}
+
+// Test: Text comparator
+func TestDiffText(t *testing.T) {
+	dir, err := ioutil.TempDir("", "deer-diff-")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	session := &executor.JudgeSession{ConfigDir: dir, SessionDir: dir}
+	session.JudgeConfig.FileSizeLimit = 1024 * 1024 * 1024
+	diff := func(userout, answer string) *commonStructs.TestCaseResult {
+		rst := &commonStructs.TestCaseResult{Output: "answer", ProgramOut: "userout"}
+		if err := ioutil.WriteFile(path.Join(dir, "userout"), []byte(userout), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path.Join(dir, "answer"), []byte(answer), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := session.DiffText(rst); err != nil {
+			t.Fatal(err)
+		}
+		return rst
+	}
+	cases := []struct {
+		userout    string
+		answer     string
+		verdict    int
+		sameLines  int
+		totalLines int
+	}{
+		{"1 2\n3 4\n", "1 2\n3 4\n", constants.JudgeFlagAC, 0, 0},
+		{"1 2 \n3 4\n", "1 2\n3 4\n ", constants.JudgeFlagPE, 0, 0},
+		{"1\t2\n3 4\n", "1 2\n3 4\n", constants.JudgeFlagPE, 0, 0},
+		{"1 2\n\n3 4", "1 2\n3 4\n", constants.JudgeFlagPE, 0, 0},
+		{"1 2\n3 5\n", "1 2\n3 4\n", constants.JudgeFlagWA, 1, 2},
+		{"1 2\n\n3 4\n5\n", "1 2\n3  4\n6\n", constants.JudgeFlagWA, 2, 3},
+		{"1 2\n", "1 2\n3 4\n", constants.JudgeFlagWA, 1, 2},
+	}
+	for _, c := range cases {
+		rst := diff(c.userout, c.answer)
+		if rst.JudgeResult != c.verdict || rst.SameLines != c.sameLines || rst.TotalLines != c.totalLines {
+			t.Fatalf("DiffText(%q, %q): expect %d %d/%d, got %d %d/%d (%s)", c.userout, c.answer,
+				c.verdict, c.sameLines, c.totalLines, rst.JudgeResult, rst.SameLines, rst.TotalLines, rst.TextDiffLog)
+			return
+		}
+	}
+
+	// 大文件只映射到内存，比较的时候不会分配与文件大小相当的内存
+	lines := 2000000
+	write := func(name, last string) {
+		fp, err := os.Create(path.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer fp.Close()
+		w := bufio.NewWriter(fp)
+		for i := 1; i < lines; i++ {
+			_, _ = w.WriteString("123456789\n")
+		}
+		_, _ = w.WriteString(last)
+		_ = w.Flush()
+	}
+	write("userout", "123456780\n")
+	write("answer", "123456789\n")
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	rst := &commonStructs.TestCaseResult{Output: "answer", ProgramOut: "userout"}
+	if err := session.DiffText(rst); err != nil {
+		t.Fatal(err)
+		return
+	}
+	runtime.ReadMemStats(&after)
+	if rst.JudgeResult != constants.JudgeFlagWA || rst.SameLines != lines-1 || rst.TotalLines != lines {
+		t.Fatalf("large file: expect WA %d/%d, got %d %d/%d", lines-1, lines, rst.JudgeResult, rst.SameLines, rst.TotalLines)
+		return
+	}
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1024*1024 {
+		t.Fatalf("large file: allocated %d bytes", allocated)
+		return
+	}
+	t.Log("OK")
+}
+
+// Test: Float mode of the text comparator
+// 用例里的程序只能输出有限的几种数字，这里补充特殊值、非数字的单词和单词数量不一致的情况
+func TestDiffTextFloat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "deer-diff-")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	session := &executor.JudgeSession{ConfigDir: dir, SessionDir: dir}
+	session.JudgeConfig.FileSizeLimit = 1024 * 1024
+	session.JudgeConfig.Compare.Mode = constants.CompareModeFloat
+	cases := []struct {
+		userout    string
+		answer     string
+		verdict    int
+		log        string
+		sameLines  int
+		totalLines int
+	}{
+		{"nan inf -inf\n", "NaN +Inf -Inf\n", constants.JudgeFlagAC, "Accepted.", 0, 0},
+		{"1.0000001\n\n1000.0005\n", "1\n1000\n", constants.JudgeFlagAC, "Accepted.", 0, 0},
+		{"inf\n", "-inf\n", constants.JudgeFlagWA, `WA: at token=0, expected="-inf", found="inf", absError=+Inf`, 0, 1},
+		{"1 yes\n", "1 no\n", constants.JudgeFlagWA, `WA: at token=1, expected="no", found="yes"`, 0, 1},
+		{"1 two\n", "1 2\n", constants.JudgeFlagWA, `WA: at token=1, expected="2", found="two", not a number`, 0, 1},
+		{"1 2\n", "1 2\n3 4\n", constants.JudgeFlagWA, "WA: at token=2, useroutTokens=2, answerTokens=4", 1, 2},
+		{"1 2\n3 4 5\n", "1 2\n3 4\n", constants.JudgeFlagWA, "WA: at token=4, useroutTokens=5, answerTokens=4", 1, 2},
+	}
+	for _, c := range cases {
+		if err := ioutil.WriteFile(path.Join(dir, "userout"), []byte(c.userout), 0644); err != nil {
+			t.Fatal(err)
+			return
+		}
+		if err := ioutil.WriteFile(path.Join(dir, "answer"), []byte(c.answer), 0644); err != nil {
+			t.Fatal(err)
+			return
+		}
+		rst := &commonStructs.TestCaseResult{Output: "answer", ProgramOut: "userout", Visible: true}
+		if err := session.DiffText(rst); err != nil {
+			t.Fatal(err)
+			return
+		}
+		if rst.JudgeResult != c.verdict || !strings.Contains(rst.TextDiffLog, c.log) || rst.SameLines != c.sameLines || rst.TotalLines != c.totalLines {
+			t.Fatalf("DiffText(%q, %q): expect %d %q %d/%d, got %d %q %d/%d", c.userout, c.answer,
+				c.verdict, c.log, c.sameLines, c.totalLines, rst.JudgeResult, rst.TextDiffLog, rst.SameLines, rst.TotalLines)
+			return
+		}
+	}
+	t.Log("OK")
+}
+
+// Test: Mismatch report of the text comparator
+func TestDiffReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "deer-diff-")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	session := &executor.JudgeSession{ConfigDir: dir, SessionDir: dir}
+	session.JudgeConfig.FileSizeLimit = 1024 * 1024
+	diff := func(userout, answer string, visible bool) *commonStructs.TestCaseResult {
+		rst := &commonStructs.TestCaseResult{Output: "answer", ProgramOut: "userout", Visible: visible}
+		if err := ioutil.WriteFile(path.Join(dir, "userout"), []byte(userout), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path.Join(dir, "answer"), []byte(answer), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := session.DiffText(rst); err != nil {
+			t.Fatal(err)
+		}
+		return rst
+	}
+	long := strings.Repeat("1 ", 50)
+	cases := []struct {
+		name     string
+		userout  string
+		answer   string
+		visible  bool
+		verdict  int
+		expected commonStructs.DiffReport
+	}{
+		{"wa", "1 2\n3 5\n7 8\n", "1 2\n3 4\n7 8\n", true, constants.JudgeFlagWA, commonStructs.DiffReport{
+			Line: 2, ActualLine: 2, Column: 3, ActualColumn: 3, Expected: "3 4", Actual: "3 5",
+			Context: []commonStructs.DiffContextLine{
+				{Line: 1, ActualLine: 1, Expected: "1 2", Actual: "1 2"},
+				{Line: 2, ActualLine: 2, Expected: "3 4", Actual: "3 5"},
+				{Line: 3, ActualLine: 3, Expected: "7 8", Actual: "7 8"},
+			},
+		}},
+		{"hidden", "1 2\n3 5\n7 8\n", "1 2\n3 4\n7 8\n", false, constants.JudgeFlagWA, commonStructs.DiffReport{
+			Line: 2, ActualLine: 2, Column: 3, ActualColumn: 3, Hidden: true,
+		}},
+		{"pe", "1 2 \n3\t4\n", "1 2\n3 4\n", true, constants.JudgeFlagPE, commonStructs.DiffReport{
+			Line: 1, ActualLine: 1, Column: 4, ActualColumn: 4, Expected: "1·2↵", Actual: "1·2·↵", Whitespace: true,
+			Context: []commonStructs.DiffContextLine{
+				{Line: 1, ActualLine: 1, Expected: "1·2↵", Actual: "1·2·↵"},
+				{Line: 2, ActualLine: 2, Expected: "3·4↵", Actual: "3→4↵"},
+			},
+		}},
+		{"shorter", "1\n2\n", "1\n2\n3\n", true, constants.JudgeFlagWA, commonStructs.DiffReport{
+			Line: 3, ActualLine: 3, Column: 1, ActualColumn: 1, Expected: "3", Actual: "",
+			Context: []commonStructs.DiffContextLine{
+				{Line: 1, ActualLine: 1, Expected: "1", Actual: "1"},
+				{Line: 2, ActualLine: 2, Expected: "2", Actual: "2"},
+				{Line: 3, Expected: "3"},
+			},
+		}},
+		// 上下文按照第一处不一致的行对齐
+		{"escaped", "a\\b\x01\xff\n", "a\\b\n", true, constants.JudgeFlagWA, commonStructs.DiffReport{
+			Line: 2, ActualLine: 1, Column: 1, ActualColumn: 4, Expected: "", Actual: `a\\b\x01\xff`,
+			Context: []commonStructs.DiffContextLine{
+				{Line: 1, Expected: `a\\b`},
+				{ActualLine: 1, Actual: `a\\b\x01\xff`},
+			},
+		}},
+		{"truncated", long + "2\n", long + "3\n", true, constants.JudgeFlagWA, commonStructs.DiffReport{
+			Line: 1, ActualLine: 1, Column: 101, ActualColumn: 101,
+			Expected: "..." + strings.Repeat(" 1", 31) + " 3", Actual: "..." + strings.Repeat(" 1", 31) + " 2",
+			Context: []commonStructs.DiffContextLine{
+				{Line: 1, ActualLine: 1, Expected: strings.Repeat("1 ", 32) + "...", Actual: strings.Repeat("1 ", 32) + "..."},
+			},
+		}},
+	}
+	for _, c := range cases {
+		rst := diff(c.userout, c.answer, c.visible)
+		if rst.JudgeResult != c.verdict || rst.DiffReport == nil {
+			t.Fatalf("%s: expect %d with report, got %d (%s)", c.name, c.verdict, rst.JudgeResult, rst.TextDiffLog)
+			return
+		}
+		if !reflect.DeepEqual(*rst.DiffReport, c.expected) {
+			t.Fatalf("%s: expect %+v, got %+v", c.name, c.expected, *rst.DiffReport)
+			return
+		}
+	}
+	if rst := diff("1 2\n", "1 2\n", true); rst.DiffReport != nil {
+		t.Fatalf("expect no report when accepted")
+		return
+	}
+	t.Log("OK")
+}